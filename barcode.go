@@ -3,6 +3,9 @@ package passbook
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+
+	"golang.org/x/text/encoding/ianaindex"
 )
 
 // Barcode Dictionary: Information about a pass’s barcode.
@@ -14,17 +17,141 @@ type Barcode struct {
 }
 
 func (b Barcode) Marshal() ([]byte, error) {
-	if b.Format != PKBarcodeFormatQR &&
-		b.Format != PKBarcodeFormatPDF417 &&
-		b.Format != PKBarcodeFormatAztec {
-		return nil, errors.New("Barcode format must be one of the following values: " +
-			"PKBarcodeFormatQR, PKBarcodeFormatPDF417, PKBarcodeFormatAztec")
-	}
-	if b.Message == "" {
-		return nil, errors.New("Message of barcode must be set")
+	if err := b.validate(false); err != nil {
+		return nil, err
 	}
 	if b.MessageEncoding == "" {
-		b.MessageEncoding = "iso-8859-1"
+		b.MessageEncoding = defaultMessageEncoding
 	}
 	return json.Marshal(b)
 }
+
+// validate checks the format and the message of the barcode. Code 128 format
+// is allowed only in the barcodes array.
+func (b Barcode) validate(inArray bool) error {
+	switch b.Format {
+	case PKBarcodeFormatQR, PKBarcodeFormatPDF417, PKBarcodeFormatAztec:
+	case PKBarcodeFormatCode128:
+		if !inArray {
+			return errors.New("PKBarcodeFormatCode128 is allowed only in the barcodes array")
+		}
+	default:
+		return errors.New("Barcode format must be one of the following values: " +
+			"PKBarcodeFormatQR, PKBarcodeFormatPDF417, PKBarcodeFormatAztec, PKBarcodeFormatCode128")
+	}
+	if b.Message == "" {
+		return errors.New("Message of barcode must be set")
+	}
+	return b.CheckCapacity(ErrorCorrectionMedium)
+}
+
+// barcodeWarnings returns the descriptions of the barcodes unsupported by
+// older devices.
+func (p Pass) barcodeWarnings() []string {
+	if len(p.Barcodes) == 0 || p.Barcode != nil {
+		return nil
+	}
+	for _, barcode := range p.Barcodes {
+		if barcode.Format != PKBarcodeFormatCode128 {
+			return nil
+		}
+	}
+	return []string{"barcodes contain only PKBarcodeFormatCode128, " +
+		"so older devices and Apple Watch show no barcode"}
+}
+
+// defaultMessageEncoding is the encoding of the message used when it is not set.
+const defaultMessageEncoding = "iso-8859-1"
+
+// Data returns the message of the barcode converted to the message encoding.
+// It returns an error if the encoding is not supported or the message can't
+// be represented in it.
+func (b Barcode) Data() ([]byte, error) {
+	name := b.MessageEncoding
+	if name == "" {
+		name = defaultMessageEncoding
+	}
+	encoding, err := ianaindex.IANA.Encoding(name)
+	if err != nil || encoding == nil {
+		return nil, fmt.Errorf("Unsupported message encoding %q", name)
+	}
+	data, err := encoding.NewEncoder().Bytes([]byte(b.Message))
+	if err != nil {
+		return nil, fmt.Errorf("Message of barcode can't be represented in %s encoding", name)
+	}
+	return data, nil
+}
+
+// ErrorCorrectionLevel is the level of error correction of the barcode.
+type ErrorCorrectionLevel int
+
+// Levels of error correction, from the lowest to the highest. For QR codes
+// they correspond to the levels L, M, Q and H; for Aztec codes to 10%, 23%,
+// 36% and 50% of error correction codewords; for PDF417 codes to the security
+// levels 2, 4, 5 and 6. Code 128 has no error correction. The zero level is
+// the default one, ErrorCorrectionMedium.
+const (
+	ErrorCorrectionLow ErrorCorrectionLevel = iota + 1
+	ErrorCorrectionMedium
+	ErrorCorrectionQuartile
+	ErrorCorrectionHigh
+)
+
+// barcodeCapacity lists the maximum number of bytes of the message by the
+// format and the level of error correction.
+var barcodeCapacity = map[BarcodeFormat][4]int{
+	PKBarcodeFormatQR:      {2953, 2331, 1663, 1273}, // version 40, byte mode
+	PKBarcodeFormatPDF417:  pdf417Capacity(),
+	PKBarcodeFormatAztec:   {2239, 1914, 1590, 1241}, // 32 layers, byte mode
+	PKBarcodeFormatCode128: {80, 80, 80, 80},         // longer barcodes don't fit the width of the pass
+}
+
+// pdf417Capacity returns the number of bytes that fit the PDF417 code by the
+// level of error correction. The encoder limits the code to 30 rows of 30
+// columns, that is 900 codewords instead of 928 of the specification. One of
+// them is the length descriptor, one switches to byte compaction, which is used
+// for any data, and 2^(level+1) are error correction codewords. Byte compaction
+// encodes 6 bytes by 5 codewords and the rest by one codeword per byte.
+func pdf417Capacity() (capacity [4]int) {
+	for i, level := range pdf417Levels {
+		n := 30*30 - 2 - 1<<(level+1)
+		capacity[i] = n/5*6 + n%5
+	}
+	return capacity
+}
+
+// Capacity returns the maximum number of bytes of the encoded message that
+// the barcode of this format can hold with the given level of error
+// correction. It returns 0 for unknown formats.
+func (b Barcode) Capacity(level ErrorCorrectionLevel) int {
+	if level == 0 {
+		level = ErrorCorrectionMedium
+	}
+	capacity, ok := barcodeCapacity[b.Format]
+	if !ok || level < ErrorCorrectionLow || level > ErrorCorrectionHigh {
+		return 0
+	}
+	return capacity[level-1]
+}
+
+// CheckCapacity checks that the message can be represented in the message
+// encoding and fits the barcode with the given level of error correction.
+// Code 128 allows only ASCII characters.
+func (b Barcode) CheckCapacity(level ErrorCorrectionLevel) error {
+	data, err := b.Data()
+	if err != nil {
+		return err
+	}
+	if b.Format == PKBarcodeFormatCode128 {
+		for _, c := range data {
+			if c > 127 {
+				return errors.New("Message of PKBarcodeFormatCode128 barcode must contain only ASCII characters")
+			}
+		}
+	}
+	if capacity := b.Capacity(level); len(data) > capacity {
+		return fmt.Errorf("Message of %s barcode is %d bytes long, but only %d bytes fit",
+			b.Format, len(data), capacity)
+	}
+	return nil
+}
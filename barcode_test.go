@@ -0,0 +1,102 @@
+package passbook
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestBarcodeCapacity(t *testing.T) {
+	for _, test := range []struct {
+		barcode Barcode
+		level   ErrorCorrectionLevel
+		err     string
+	}{
+		{Barcode{Format: PKBarcodeFormatQR, Message: "héllo"}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatQR, Message: "Привет", MessageEncoding: "iso-8859-1"}, ErrorCorrectionMedium, "can't be represented"},
+		{Barcode{Format: PKBarcodeFormatQR, Message: "Привет", MessageEncoding: "utf-8"}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatQR, Message: "Привет", MessageEncoding: "windows-1251"}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatQR, Message: "1", MessageEncoding: "x-unknown"}, ErrorCorrectionMedium, "Unsupported"},
+		{Barcode{Format: PKBarcodeFormatQR, Message: strings.Repeat("x", 2331)}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatQR, Message: strings.Repeat("x", 2331)}, ErrorCorrectionHigh, "only 1273 bytes"},
+		{Barcode{Format: PKBarcodeFormatQR, Message: strings.Repeat("Ж", 1200), MessageEncoding: "utf-8"}, ErrorCorrectionMedium, "2400 bytes"},
+		{Barcode{Format: PKBarcodeFormatPDF417, Message: strings.Repeat("x", 1040)}, ErrorCorrectionMedium, "only 1039 bytes"},
+		{Barcode{Format: PKBarcodeFormatAztec, Message: strings.Repeat("x", 1914)}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatCode128, Message: "ABC-123"}, ErrorCorrectionMedium, ""},
+		{Barcode{Format: PKBarcodeFormatCode128, Message: "café"}, ErrorCorrectionMedium, "ASCII"},
+		{Barcode{Format: PKBarcodeFormatCode128, Message: strings.Repeat("1", 81)}, ErrorCorrectionMedium, "only 80 bytes"},
+	} {
+		err := test.barcode.CheckCapacity(test.level)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s %.10q: unexpected error: %v", test.barcode.Format, test.barcode.Message, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s %.10q: error %q expected, got %v", test.barcode.Format, test.barcode.Message, test.err, err)
+		}
+	}
+	// Code 128 must not be the only format for older devices
+	pass := testPass("generic")
+	pass.Barcodes = []Barcode{{Format: PKBarcodeFormatCode128, Message: "123"}}
+	if err := pass.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := pass.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "Code128") {
+		t.Errorf("warnings: %q", warnings)
+	}
+	pass.Barcodes = append(pass.Barcodes, Barcode{Format: PKBarcodeFormatQR, Message: "123"})
+	if warnings := pass.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings: %q", warnings)
+	}
+}
+
+// TestBarcodeCapacityFits checks that the messages of the maximum size are
+// encoded at every level of error correction.
+func TestBarcodeCapacityFits(t *testing.T) {
+	for _, format := range []BarcodeFormat{PKBarcodeFormatQR, PKBarcodeFormatPDF417, PKBarcodeFormatAztec} {
+		for level := ErrorCorrectionLow; level <= ErrorCorrectionHigh; level++ {
+			barcode := Barcode{Format: format, MessageEncoding: "iso-8859-1"}
+			barcode.Message = strings.Repeat("é", barcode.Capacity(level)) // binary data
+			if _, err := barcode.Matrix(level); err != nil {
+				t.Errorf("%s, level %d: %v", format, level, err)
+			}
+		}
+	}
+}
+
+func TestBarcodeImage(t *testing.T) {
+	for _, format := range []BarcodeFormat{PKBarcodeFormatQR, PKBarcodeFormatPDF417,
+		PKBarcodeFormatAztec, PKBarcodeFormatCode128} {
+		barcode := Barcode{Format: format, Message: "Ticket 123456"}
+		matrix, err := barcode.Matrix(0)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		opts := &BarcodeImageOptions{ModuleSize: 2, Height: 20}
+		img, err := barcode.Image(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		height := matrix.Height
+		if matrix.Linear {
+			height = opts.Height
+		}
+		size := img.Bounds().Size()
+		if size.X != (matrix.Width+2*matrix.QuietZone)*2 || size.Y != (height+2*matrix.QuietZone)*2 {
+			t.Errorf("%s: image size %v for %dx%d modules", format, size, matrix.Width, height)
+		}
+		if gray := color.GrayModel.Convert(img.At(0, 0)).(color.Gray); gray.Y != 0xff {
+			t.Errorf("%s: quiet zone is not white", format)
+		}
+		var buf bytes.Buffer
+		if err := barcode.WriteSVG(&buf, nil); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("<svg ")) || !bytes.Contains(buf.Bytes(), []byte("<rect x=")) {
+			t.Errorf("%s: bad SVG: %.100s", format, buf.String())
+		}
+	}
+	if _, err := (Barcode{Format: PKBarcodeFormatCode128, Message: "café"}).Image(nil); err == nil {
+		t.Error("non-ASCII Code 128 message rendered")
+	}
+}
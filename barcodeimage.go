@@ -0,0 +1,233 @@
+package passbook
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/aztec"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/boombuler/barcode/qr"
+	"github.com/mdigger/passbook/internal/format"
+)
+
+// BarcodeMatrix is the grid of the modules of the barcode. Linear barcodes,
+// such as Code 128, have the height of one module.
+type BarcodeMatrix struct {
+	Width, Height int    // Size of the barcode in modules, without the quiet zone.
+	QuietZone     int    // Minimum width of the quiet zone around the barcode in modules.
+	Linear        bool   // The barcode is linear and may be stretched vertically.
+	Dark          []bool // Dark modules by rows.
+}
+
+// At returns true if the module with the given coordinates is dark.
+func (m *BarcodeMatrix) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return false
+	}
+	return m.Dark[y*m.Width+x]
+}
+
+// Levels of error correction by the formats.
+var (
+	qrLevels     = [...]qr.ErrorCorrectionLevel{qr.L, qr.M, qr.Q, qr.H}
+	aztecLevels  = [...]int{10, 23, 36, 50}
+	pdf417Levels = [...]byte{2, 4, 5, 6}
+)
+
+// Matrix encodes the message of the barcode in the message encoding, as Wallet
+// does, and returns the grid of its modules.
+func (b Barcode) Matrix(level ErrorCorrectionLevel) (*BarcodeMatrix, error) {
+	if level == 0 {
+		level = ErrorCorrectionMedium
+	}
+	if level < ErrorCorrectionLow || level > ErrorCorrectionHigh {
+		return nil, fmt.Errorf("Bad error correction level %d", level)
+	}
+	if err := b.CheckCapacity(level); err != nil {
+		return nil, err
+	}
+	data, err := b.Data()
+	if err != nil {
+		return nil, err
+	}
+	var code barcode.Barcode
+	matrix := new(BarcodeMatrix)
+	rowHeight, rowModules := 1, 1
+	switch b.Format {
+	case PKBarcodeFormatQR:
+		// the bytes of the message are encoded in byte mode as is
+		code, err = qr.Encode(string(data), qrLevels[level-1], qr.Unicode)
+		matrix.QuietZone = 4
+	case PKBarcodeFormatPDF417:
+		code, err = pdf417.Encode(string(data), pdf417Levels[level-1])
+		matrix.QuietZone = 2
+		// the rows are encoded two pixels high, but drawn three modules high
+		rowHeight, rowModules = 2, 3
+	case PKBarcodeFormatAztec:
+		code, err = aztec.Encode(data, aztecLevels[level-1], 0)
+		matrix.QuietZone = 1
+	case PKBarcodeFormatCode128:
+		code, err = code128.Encode(string(data))
+		matrix.QuietZone = 10
+		matrix.Linear = true
+	default:
+		return nil, fmt.Errorf("Unsupported barcode format %q", b.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bounds := code.Bounds()
+	matrix.Width, matrix.Height = bounds.Dx(), bounds.Dy()/rowHeight*rowModules
+	matrix.Dark = make([]bool, matrix.Width*matrix.Height)
+	for y := 0; y < matrix.Height; y++ {
+		for x := 0; x < matrix.Width; x++ {
+			gray := color.GrayModel.Convert(code.At(bounds.Min.X+x,
+				bounds.Min.Y+y/rowModules*rowHeight)).(color.Gray)
+			matrix.Dark[y*matrix.Width+x] = gray.Y < 0x80
+		}
+	}
+	return matrix, nil
+}
+
+// BarcodeImageOptions are the options of the rendering of barcodes.
+type BarcodeImageOptions struct {
+	Level      ErrorCorrectionLevel // Level of error correction; ErrorCorrectionMedium if zero.
+	ModuleSize int                  // Size of the module in pixels; 4 if zero.
+	QuietZone  int                  // Width of the quiet zone in modules; the minimum for the format if zero.
+	Height     int                  // Height of linear barcodes in modules; 50 if zero.
+	Foreground color.Color          // Color of dark modules; black if nil.
+	Background color.Color          // Color of light modules and the quiet zone; white if nil.
+}
+
+// layout returns the options with the defaults and the size of the barcode
+// with the quiet zone in modules.
+func (opts *BarcodeImageOptions) layout(m *BarcodeMatrix) (o BarcodeImageOptions, width, height int) {
+	if opts != nil {
+		o = *opts
+	}
+	if o.ModuleSize <= 0 {
+		o.ModuleSize = 4
+	}
+	if o.QuietZone <= 0 {
+		o.QuietZone = m.QuietZone
+	}
+	if o.Height <= 0 {
+		o.Height = 50
+	}
+	if o.Foreground == nil {
+		o.Foreground = color.Black
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+	height = m.Height
+	if m.Linear {
+		height = o.Height
+	}
+	return o, m.Width + 2*o.QuietZone, height + 2*o.QuietZone
+}
+
+// dark returns true if the module of the barcode with the quiet zone is dark.
+func (m *BarcodeMatrix) dark(x, y int, o BarcodeImageOptions) bool {
+	x, y = x-o.QuietZone, y-o.QuietZone
+	if m.Linear {
+		if y < 0 || y >= o.Height {
+			return false
+		}
+		y = 0
+	}
+	return m.At(x, y)
+}
+
+// Image returns the image of the barcode with the quiet zone around it.
+func (b Barcode) Image(opts *BarcodeImageOptions) (image.Image, error) {
+	m, err := b.Matrix(opts.level())
+	if err != nil {
+		return nil, err
+	}
+	o, width, height := opts.layout(m)
+	img := image.NewPaletted(image.Rect(0, 0, width*o.ModuleSize, height*o.ModuleSize),
+		color.Palette{o.Background, o.Foreground})
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !m.dark(x, y, o) {
+				continue
+			}
+			for py := y * o.ModuleSize; py < (y+1)*o.ModuleSize; py++ {
+				for px := x * o.ModuleSize; px < (x+1)*o.ModuleSize; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// level returns the level of error correction from the options.
+func (opts *BarcodeImageOptions) level() ErrorCorrectionLevel {
+	if opts == nil {
+		return 0
+	}
+	return opts.Level
+}
+
+// WritePNG writes the image of the barcode to w in PNG format.
+func (b Barcode) WritePNG(w io.Writer, opts *BarcodeImageOptions) error {
+	img, err := b.Image(opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WriteSVG writes the image of the barcode to w in SVG format. The dark
+// modules are drawn as rectangles, one per horizontal run.
+func (b Barcode) WriteSVG(w io.Writer, opts *BarcodeImageOptions) error {
+	m, err := b.Matrix(opts.level())
+	if err != nil {
+		return err
+	}
+	o, width, height := opts.layout(m)
+	bw := bufio.NewWriter(w)
+	size := o.ModuleSize
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		width*size, height*size, width, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, format.CSSColor(o.Background))
+	fmt.Fprintf(bw, `<g fill="%s">`+"\n", format.CSSColor(o.Foreground))
+	if m.Linear {
+		// the bars are drawn once for the whole height
+		for x := 0; x < width; x++ {
+			if !m.dark(x, o.QuietZone, o) {
+				continue
+			}
+			run := 1
+			for x+run < width && m.dark(x+run, o.QuietZone, o) {
+				run++
+			}
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d"/>`+"\n", x, o.QuietZone, run, o.Height)
+			x += run
+		}
+	} else {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if !m.dark(x, y, o) {
+					continue
+				}
+				run := 1
+				for x+run < width && m.dark(x+run, y, o) {
+					run++
+				}
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="1"/>`+"\n", x, y, run)
+				x += run
+			}
+		}
+	}
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
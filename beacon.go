@@ -3,6 +3,8 @@ package passbook
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // Beacon Dictionary: Information about a location beacon. Available in iOS 7.0.
@@ -14,8 +16,53 @@ type Beacon struct {
 }
 
 func (b Beacon) Marshal() ([]byte, error) {
-	if b.ProximityUUID == "" {
-		return nil, errors.New("Unique identifier of a Bluetooth Low Energy location beacon must be set")
+	if err := b.validate(); err != nil {
+		return nil, err
 	}
+	b.ProximityUUID = b.uuid()
 	return json.Marshal(b)
 }
+
+// validate checks that the proximity UUID is in the canonical form, such as
+// E2C56DB5-DFFB-48D2-B060-D0F5A71096E0. The UUID may be enclosed in braces.
+func (b Beacon) validate() error {
+	if b.ProximityUUID == "" {
+		return errors.New("Unique identifier of a Bluetooth Low Energy location beacon must be set")
+	}
+	if _, ok := canonicalUUID(b.ProximityUUID); !ok {
+		return fmt.Errorf("Proximity UUID %q must be in the form XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX", b.ProximityUUID)
+	}
+	return nil
+}
+
+// uuid returns the proximity UUID in the canonical form used to compare and
+// store beacons. The invalid UUID is returned in lower case.
+func (b Beacon) uuid() string {
+	if uuid, ok := canonicalUUID(b.ProximityUUID); ok {
+		return uuid
+	}
+	return strings.ToLower(b.ProximityUUID)
+}
+
+// canonicalUUID returns the UUID of 32 hexadecimal digits separated by hyphens
+// into groups of 8-4-4-4-12 digits in lower case. The UUID may be in upper
+// case and enclosed in braces. If the string is not UUID, false is returned.
+func canonicalUUID(s string) (string, bool) {
+	if len(s) == 38 && s[0] == '{' && s[37] == '}' {
+		s = s[1:37]
+	}
+	if len(s) != 36 {
+		return "", false
+	}
+	for i, r := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if r != '-' {
+				return "", false
+			}
+		case !('0' <= r && r <= '9' || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F'):
+			return "", false
+		}
+	}
+	return strings.ToLower(s), true
+}
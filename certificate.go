@@ -0,0 +1,147 @@
+package passbook
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNotPassCertificate is returned when the certificate was not issued by
+// Apple for signing passes.
+var ErrNotPassCertificate = errors.New("certificate is not a pass type certificate")
+
+var (
+	// oidUserID is the object identifier of the user ID attribute of the
+	// subject, which contains the pass type identifier.
+	oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+	// oidPassTypeExtension is the object identifier of Apple's extension of
+	// pass type certificates.
+	oidPassTypeExtension = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 16}
+)
+
+// CertificateSigner is a Signer that provides its certificate. The Writer uses
+// the certificate to check and fill the identifiers of the pass.
+type CertificateSigner interface {
+	Signer
+	SigningCertificate() *x509.Certificate
+}
+
+// CertificateIdentifiers returns the pass type identifier and the team
+// identifier from the subject of the pass type certificate. The pass type
+// identifier is stored in the user ID attribute and the team identifier in
+// the organizational unit. ErrNotPassCertificate is returned if the certificate
+// has no Apple's pass type extension or identifiers.
+func CertificateIdentifiers(cert *x509.Certificate) (passTypeIdentifier, teamIdentifier string, err error) {
+	var hasExtension bool
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidPassTypeExtension) {
+			hasExtension = true
+			break
+		}
+	}
+	for _, name := range cert.Subject.Names {
+		if name.Type.Equal(oidUserID) {
+			passTypeIdentifier, _ = name.Value.(string)
+		}
+	}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		teamIdentifier = cert.Subject.OrganizationalUnit[0]
+	}
+	if !hasExtension || passTypeIdentifier == "" || teamIdentifier == "" {
+		return passTypeIdentifier, teamIdentifier, ErrNotPassCertificate
+	}
+	return passTypeIdentifier, teamIdentifier, nil
+}
+
+// fillIdentifiers sets the empty pass type and team identifiers of the pass to
+// the values from the certificate and checks that the set ones match it.
+func (p *Pass) fillIdentifiers(cert *x509.Certificate) error {
+	passTypeIdentifier, teamIdentifier, err := CertificateIdentifiers(cert)
+	if err != nil {
+		return err
+	}
+	if p.PassTypeIdentifier == "" {
+		p.PassTypeIdentifier = passTypeIdentifier
+	} else if p.PassTypeIdentifier != passTypeIdentifier {
+		return fmt.Errorf("Pass Type Identifier %q does not match certificate %q",
+			p.PassTypeIdentifier, passTypeIdentifier)
+	}
+	if p.TeamIdentifier == "" {
+		p.TeamIdentifier = teamIdentifier
+	} else if p.TeamIdentifier != teamIdentifier {
+		return fmt.Errorf("Team Identifier %q does not match certificate %q",
+			p.TeamIdentifier, teamIdentifier)
+	}
+	return nil
+}
+
+// fillPassIdentifiers fills the identifiers in the JSON description of the pass
+// like Pass.fillIdentifiers. The description is encoded again only if it was changed.
+func fillPassIdentifiers(passData []byte, cert *x509.Certificate) ([]byte, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(passData, &keys); err != nil {
+		return nil, err
+	}
+	var pass Pass
+	for key, field := range map[string]*string{
+		"passTypeIdentifier": &pass.PassTypeIdentifier,
+		"teamIdentifier":     &pass.TeamIdentifier,
+	} {
+		if value, ok := keys[key]; ok {
+			if err := json.Unmarshal(value, field); err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+		}
+	}
+	filled := pass
+	if err := filled.fillIdentifiers(cert); err != nil {
+		return nil, err
+	}
+	if filled.PassTypeIdentifier == pass.PassTypeIdentifier &&
+		filled.TeamIdentifier == pass.TeamIdentifier {
+		return passData, nil
+	}
+	keys["passTypeIdentifier"], _ = json.Marshal(filled.PassTypeIdentifier)
+	keys["teamIdentifier"], _ = json.Marshal(filled.TeamIdentifier)
+	return json.Marshal(keys)
+}
+
+// ErrKeyMismatch is returned when the private key does not belong to the certificate.
+var ErrKeyMismatch = errors.New("private key does not match certificate")
+
+// NewCertificateRequest generates a new 2048-bit RSA private key and the
+// certificate signing request for the pass type identifier. The request in
+// PEM format is uploaded to Apple Developer site to get the pass type
+// certificate.
+func NewCertificateRequest(passTypeIdentifier, email string) (*rsa.PrivateKey, []byte, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: passTypeIdentifier},
+		SignatureAlgorithm: x509.SHA256WithRSA,
+	}
+	if email != "" {
+		template.EmailAddresses = []string{email}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// DaysUntilExpiry returns the number of whole days from now until the
+// certificate expires. The number is negative for expired certificates.
+func DaysUntilExpiry(cert *x509.Certificate, now time.Time) int {
+	return int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+}
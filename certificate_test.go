@@ -0,0 +1,137 @@
+package passbook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestCertificateIdentifiers(t *testing.T) {
+	passTypeIdentifier, teamIdentifier, err := CertificateIdentifiers(testIdentity(t, true).Certificate)
+	if err != nil || passTypeIdentifier != "pass.com.example.test" || teamIdentifier != "A93A5CM278" {
+		t.Errorf("identifiers: %q, %q, %v", passTypeIdentifier, teamIdentifier, err)
+	}
+	if _, _, err := CertificateIdentifiers(testIdentity(t, false).Certificate); err != ErrNotPassCertificate {
+		t.Errorf("certificate without extension: %v", err)
+	}
+}
+
+func TestNewCertificateRequest(t *testing.T) {
+	priv, data, err := NewCertificateRequest("pass.com.example.test", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.N.BitLen() != 2048 {
+		t.Errorf("key size: %d", priv.N.BitLen())
+	}
+	block, rest := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" || len(rest) != 0 {
+		t.Fatalf("bad PEM request: %q", data)
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := request.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+	if request.Subject.CommonName != "pass.com.example.test" {
+		t.Errorf("common name: %q", request.Subject.CommonName)
+	}
+	if len(request.EmailAddresses) != 1 || request.EmailAddresses[0] != "test@example.com" {
+		t.Errorf("email addresses: %q", request.EmailAddresses)
+	}
+	if !priv.PublicKey.Equal(request.PublicKey) {
+		t.Error("request has another public key")
+	}
+	_, data, err = NewCertificateRequest("pass.com.example.test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode(data)
+	if request, err = x509.ParseCertificateRequest(block.Bytes); err != nil {
+		t.Fatal(err)
+	}
+	if len(request.EmailAddresses) != 0 {
+		t.Errorf("email addresses: %q", request.EmailAddresses)
+	}
+}
+
+func TestDaysUntilExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		notAfter time.Time
+		days     int
+	}{
+		{now.Add(30 * 24 * time.Hour), 30},
+		{now.Add(30*24*time.Hour - time.Second), 29},
+		{now.Add(time.Hour), 0},
+		{now, 0},
+		{now.Add(-time.Hour), -1},
+		{now.Add(-48 * time.Hour), -2},
+	} {
+		cert := &x509.Certificate{NotAfter: test.notAfter}
+		if days := DaysUntilExpiry(cert, now); days != test.days {
+			t.Errorf("%v: %d days, want %d", test.notAfter, days, test.days)
+		}
+	}
+}
+
+func TestNewIdentity(t *testing.T) {
+	identity := testIdentity(t, true)
+	if _, err := NewIdentity(identity.Certificate, identity.PrivateKey); err != nil {
+		t.Error(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIdentity(identity.Certificate, other); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("other key: %v", err)
+	}
+}
+
+func TestIdentityMarshal(t *testing.T) {
+	identity := testIdentity(t, true)
+	data, err := identity.MarshalPKCS12("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, cert, err := pkcs12.Decode(data, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cert.Equal(identity.Certificate) {
+		t.Error("PKCS #12: another certificate")
+	}
+	if key, ok := priv.(*rsa.PrivateKey); !ok || !key.Equal(identity.PrivateKey) {
+		t.Error("PKCS #12: another private key")
+	}
+	if _, _, err := pkcs12.Decode(data, "wrong"); err == nil {
+		t.Error("PKCS #12: wrong password is accepted")
+	}
+
+	data = identity.MarshalPEM()
+	block, rest := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" || !bytes.Equal(block.Bytes, identity.Certificate.Raw) {
+		t.Fatal("PEM: no certificate")
+	}
+	block, _ = pem.Decode(rest)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		t.Fatal("PEM: no private key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIdentity(identity.Certificate, key); err != nil {
+		t.Error("PEM:", err)
+	}
+}
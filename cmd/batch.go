@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mdigger/commitfile"
+	"github.com/mdigger/passbook"
+)
+
+// record описывает строку с данными для одного passbook.
+type record struct {
+	row  int         // номер записи CSV или строки файла JSON Lines, начиная с 1
+	data interface{} // данные для шаблона
+	err  error       // ошибка разбора строки
+}
+
+// failure описывает ошибку создания passbook для строки с данными.
+type failure struct {
+	row int
+	err error
+}
+
+// batch создает по одному passbook на каждую строку файла с данными в формате
+// CSV или JSON Lines, используя каталог с шаблоном.
+func batch(args []string) {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	var certFilename, privFilename, passwd, outDir, format string
+	var workers int
+	flags.StringVar(&certFilename, "cert", "cert.cer", "file with x509 Certificate")
+	flags.StringVar(&privFilename, "key", "key.pem", "file with Private key")
+	flags.StringVar(&passwd, "pass", "", "password for Private key")
+	flags.StringVar(&outDir, "out", ".", "output directory for passbook files")
+	flags.StringVar(&format, "format", "", "format of data file: csv or jsonl (default by extension)")
+	flags.IntVar(&workers, "workers", runtime.NumCPU(), "number of parallel workers")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s batch [options] template_dir data_file:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	dataFilename := flags.Arg(1)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(dataFilename)) {
+		case ".jsonl", ".ndjson":
+			format = "jsonl"
+		default:
+			format = "csv"
+		}
+	}
+	var read func(io.Reader, chan<- record)
+	switch format {
+	case "csv":
+		read = readCSV
+	case "jsonl":
+		read = readJSONLines
+	default:
+		log.Fatalf("Unsupported data format %q", format)
+	}
+	identity := loadIdentity(certFilename, privFilename, passwd)
+	// шаблон разбираем только один раз и используем для всех строк
+	log.Printf("Parsing template %q", flags.Arg(0))
+	tmpl, err := passbook.ParseTemplateDir(flags.Arg(0))
+	if err != nil {
+		log.Fatalln("Error parsing template:", err)
+	}
+	dataFile, err := os.Open(dataFilename)
+	if err != nil {
+		log.Fatalln("Error opening data file:", err)
+	}
+	defer dataFile.Close()
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		log.Fatalln("Error creating output directory:", err)
+	}
+	records := make(chan record)
+	go func() {
+		read(dataFile, records)
+		close(records)
+	}()
+	// параллельно создаем passbook-файлы
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		created  int
+		skipped  int
+		failures []failure
+		serials  = make(map[string]int) // номера строк по серийным номерам
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range records {
+				done, err := false, rec.err
+				if err == nil {
+					done, err = batchPass(tmpl, identity, outDir, rec, &mu, serials)
+				}
+				mu.Lock()
+				switch {
+				case err != nil:
+					failures = append(failures, failure{rec.row, err})
+				case done:
+					created++
+				default:
+					skipped++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	log.Printf("Created: %d, skipped: %d, failed: %d", created, skipped, len(failures))
+	if len(failures) == 0 {
+		return
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].row < failures[j].row })
+	for _, f := range failures {
+		log.Printf("Row %d: %v", f.row, f.err)
+	}
+	os.Exit(1)
+}
+
+// batchPass создает passbook для строки с данными. Описание passbook создается
+// по шаблону один раз и используется и для проверки серийного номера, и для
+// записи файла. Если файл с таким серийным номером уже существует, то он
+// пропускается и возвращается false.
+func batchPass(tmpl *passbook.Template, identity *passbook.Identity, outDir string,
+	rec record, mu *sync.Mutex, serials map[string]int) (bool, error) {
+	prepared, err := tmpl.Prepare(identity, rec.data)
+	if err != nil {
+		return false, err
+	}
+	name := prepared.Pass.SerialNumber
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return false, fmt.Errorf("serial number %q can't be used as filename", name)
+	}
+	// проверяем, что серийный номер не встречался в других строках
+	mu.Lock()
+	row, ok := serials[name]
+	if !ok {
+		serials[name] = rec.row
+	}
+	mu.Unlock()
+	if ok {
+		return false, fmt.Errorf("serial number %q duplicates row %d", name, row)
+	}
+	filename := filepath.Join(outDir, name+".pkpass")
+	// файлы, созданные при предыдущем запуске, пропускаем
+	if _, err := os.Stat(filename); err == nil {
+		return false, nil
+	}
+	file, err := commitfile.Create(filename)
+	if err != nil {
+		return false, err
+	}
+	if err := prepared.Write(file); err != nil {
+		file.Close()
+		return false, err
+	}
+	file.Commit() // подтверждаем успешное создание
+	if err := file.Close(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// readCSV читает данные в формате CSV. Первая строка содержит названия колонок,
+// которые используются как ключи данных для шаблона.
+func readCSV(r io.Reader, records chan<- record) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	header, err := csvReader.Read()
+	if err != nil {
+		if err != io.EOF {
+			records <- record{row: 0, err: err}
+		}
+		return
+	}
+	for row := 1; ; row++ {
+		values, err := csvReader.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			records <- record{row: row, err: err}
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return // ошибка чтения файла
+			}
+			continue
+		}
+		if len(values) != len(header) {
+			records <- record{row: row, err: fmt.Errorf("%d values for %d columns", len(values), len(header))}
+			continue
+		}
+		data := make(map[string]string, len(header))
+		for i, name := range header {
+			data[name] = values[i]
+		}
+		records <- record{row: row, data: data}
+	}
+}
+
+// readJSONLines читает данные в формате JSON Lines: каждая непустая строка
+// содержит JSON-объект с данными для шаблона. Пустые строки пропускаются, но
+// учитываются в номерах строк.
+func readJSONLines(r io.Reader, records chan<- record) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	row := 0
+	for scanner.Scan() {
+		row++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &data); err != nil {
+			records <- record{row: row, err: err}
+			continue
+		}
+		records <- record{row: row, data: data}
+	}
+	if err := scanner.Err(); err != nil {
+		records <- record{row: row + 1, err: err}
+	}
+}
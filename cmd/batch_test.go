@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/passbooktest"
+)
+
+func TestReadJSONLines(t *testing.T) {
+	records := make(chan record)
+	go func() {
+		readJSONLines(strings.NewReader("{\"serial\":\"1\"}\n\n  \n{bad\n{\"serial\":\"2\"}\n"), records)
+		close(records)
+	}()
+	var rows []int
+	var errors int
+	for rec := range records {
+		rows = append(rows, rec.row)
+		if rec.err != nil {
+			errors++
+		}
+	}
+	if len(rows) != 3 || rows[0] != 1 || rows[1] != 4 || rows[2] != 5 || errors != 1 {
+		t.Errorf("rows %v with %d errors, want [1 4 5] with 1 error", rows, errors)
+	}
+}
+
+func TestBatchPass(t *testing.T) {
+	identity, _ := passbooktest.NewIdentity(t, "pass.com.example.test", "A1B2C3D4E5")
+	tmpl, err := passbook.ParseTemplateFS(fstest.MapFS{
+		"pass.json": {Data: []byte(`{
+	"serialNumber": {{json .serial}},
+	"organizationName": "Example",
+	"description": "Test pass",
+	"generic": {}
+}`)},
+		"icon.png": {Data: []byte("icon")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	// run creates passes for the serial numbers of rows and returns the results
+	run := func(serials ...string) []string {
+		var mu sync.Mutex
+		rows := make(map[string]int)
+		var results []string
+		for i, serial := range serials {
+			rec := record{row: i + 1, data: map[string]string{"serial": serial}}
+			switch done, err := batchPass(tmpl, identity, outDir, rec, &mu, rows); {
+			case err != nil:
+				results = append(results, err.Error())
+			case done:
+				results = append(results, "created")
+			default:
+				results = append(results, "skipped")
+			}
+		}
+		return results
+	}
+	results := run("A", "B", "A", "../C")
+	want := []string{"created", "created", `serial number "A" duplicates row 1`,
+		`serial number "../C" can't be used as filename`}
+	if strings.Join(results, "; ") != strings.Join(want, "; ") {
+		t.Errorf("results %q, want %q", results, want)
+	}
+	for _, name := range []string{"A.pkpass", "B.pkpass"} {
+		data, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		passbooktest.Open(t, data)
+	}
+	// the passes created by the previous run are skipped
+	results = run("A", "C", "B")
+	want = []string{"skipped", "created", "skipped"}
+	if strings.Join(results, "; ") != strings.Join(want, "; ") {
+		t.Errorf("results of resumed run %q, want %q", results, want)
+	}
+}
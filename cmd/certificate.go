@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdigger/passbook"
+)
+
+// csr создает новый приватный ключ и запрос на сертификат для идентификатора
+// типа passbook.
+func csr(args []string) {
+	flags := flag.NewFlagSet("csr", flag.ExitOnError)
+	var email, privFilename, csrFilename string
+	flags.StringVar(&email, "email", "", "email address for the request")
+	flags.StringVar(&privFilename, "key", "key.pem", "file for new Private key")
+	flags.StringVar(&csrFilename, "out", "request.csr", "file for Certificate signing request")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s csr [options] passTypeIdentifier:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	priv, request, err := passbook.NewCertificateRequest(flags.Arg(0), email)
+	if err != nil {
+		log.Fatalln("Error creating request:", err)
+	}
+	// не перезаписываем существующий ключ, чтобы не потерять его
+	keyData := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(priv),
+	})
+	if err := writeNewFile(privFilename, keyData, 0600); err != nil {
+		log.Fatalln("Error writing private key:", err)
+	}
+	log.Printf("Private key %q created", privFilename)
+	if err := writeNewFile(csrFilename, request, 0644); err != nil {
+		log.Fatalln("Error writing request:", err)
+	}
+	log.Printf("Certificate signing request %q created", csrFilename)
+}
+
+// export объединяет сертификат с приватным ключом и сохраняет их в формате
+// PEM или PKCS #12.
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var certFilename, privFilename, passwd, format, password string
+	var force bool
+	flags.StringVar(&certFilename, "cert", "cert.cer", "file with x509 Certificate")
+	flags.StringVar(&privFilename, "key", "key.pem", "file with Private key")
+	flags.StringVar(&passwd, "pass", "", "password for Private key")
+	flags.StringVar(&format, "format", "", "output format: pem or p12 (default by extension)")
+	flags.StringVar(&password, "password", "", "password for PKCS #12 file")
+	flags.BoolVar(&force, "force", false, "overwrite existing file")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s export [options] filename:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	filename := flags.Arg(0)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".p12", ".pfx":
+			format = "p12"
+		default:
+			format = "pem"
+		}
+	}
+	identity := loadIdentity(certFilename, privFilename, passwd)
+	var data []byte
+	switch format {
+	case "pem":
+		data = identity.MarshalPEM()
+	case "p12":
+		var err error
+		if data, err = identity.MarshalPKCS12(password); err != nil {
+			log.Fatalln("Error encoding PKCS #12:", err)
+		}
+	default:
+		log.Fatalf("Unsupported format %q", format)
+	}
+	// файл содержит приватный ключ, поэтому существующий файл перезаписывается
+	// только явно
+	write := writeNewFile
+	if force {
+		write = os.WriteFile
+	}
+	if err := write(filename, data, 0600); err != nil {
+		log.Fatalln("Error writing file:", err)
+	}
+	log.Printf("Identity %q created", filename)
+}
+
+// expiry выводит количество дней до окончания срока действия сертификатов и
+// завершается с ошибкой, если какой-то из них истекает раньше заданного срока.
+func expiry(args []string) {
+	flags := flag.NewFlagSet("expiry", flag.ExitOnError)
+	var days int
+	flags.IntVar(&days, "days", 30, "minimal number of days until expiry")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s expiry [options] certificate...:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	now := time.Now()
+	status := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, filename := range flags.Args() {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			log.Fatalln("Error reading certificate file:", err)
+		}
+		certs, err := parseCertificates(data)
+		if err != nil {
+			log.Fatalf("Error reading certificate file %q: %v", filename, err)
+		}
+		// проверяются все сертификаты файла, включая промежуточные
+		for _, cert := range certs {
+			passTypeIdentifier, _, _ := passbook.CertificateIdentifiers(cert)
+			if passTypeIdentifier == "" {
+				passTypeIdentifier = cert.Subject.CommonName
+			}
+			left := passbook.DaysUntilExpiry(cert, now)
+			mark := ""
+			if left < days {
+				mark = "!"
+				status = 1
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d days%s\n", filename, passTypeIdentifier,
+				cert.NotAfter.Format("2006-01-02"), left, mark)
+		}
+	}
+	w.Flush()
+	os.Exit(status)
+}
+
+// writeNewFile записывает данные в новый файл. Если файл уже существует,
+// то возвращается ошибка.
+func writeNewFile(filename string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdigger/passbook"
+)
+
+// inspection описывает содержимое passbook-файла.
+type inspection struct {
+	Style              string                  `json:"style"`
+	PassTypeIdentifier string                  `json:"passTypeIdentifier"`
+	TeamIdentifier     string                  `json:"teamIdentifier"`
+	SerialNumber       string                  `json:"serialNumber"`
+	OrganizationName   string                  `json:"organizationName"`
+	Description        string                  `json:"description"`
+	Sections           []inspectionSection     `json:"sections,omitempty"`
+	Barcodes           []passbook.Barcode      `json:"barcodes,omitempty"`
+	Relevance          inspectionRelevance     `json:"relevance"`
+	Compatibility      *passbook.Compatibility `json:"compatibility,omitempty"`
+	Images             []inspectionImage       `json:"images,omitempty"`
+	Localizations      []inspectionLanguage    `json:"localizations,omitempty"`
+	Certificate        *inspectionCertificate  `json:"certificate,omitempty"`
+	CertificateError   string                  `json:"certificateError,omitempty"`
+}
+
+// inspectionSection описывает поля одного раздела.
+type inspectionSection struct {
+	Name   string           `json:"name"`
+	Fields []passbook.Field `json:"fields"`
+}
+
+// inspectionRelevance описывает, когда и где passbook актуален.
+type inspectionRelevance struct {
+	RelevantDate  *time.Time              `json:"relevantDate,omitempty"`
+	RelevantDates []passbook.RelevantDate `json:"relevantDates,omitempty"`
+	MaxDistance   uint                    `json:"maxDistance,omitempty"`
+	Locations     []passbook.Location     `json:"locations,omitempty"`
+	Beacons       []passbook.Beacon       `json:"beacons,omitempty"`
+}
+
+// inspectionImage описывает картинку.
+type inspectionImage struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int    `json:"size"`
+	Error  string `json:"error,omitempty"`
+}
+
+// inspectionLanguage описывает локализацию.
+type inspectionLanguage struct {
+	Language string   `json:"language"`
+	Strings  int      `json:"strings"`
+	Files    []string `json:"files"`
+}
+
+// inspectionCertificate описывает сертификат, которым подписан passbook.
+type inspectionCertificate struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"notAfter"`
+	Expired  bool      `json:"expired"`
+}
+
+// inspect выводит информацию о содержимом passbook-файла.
+func inspect(args []string) {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var jsonOutput bool
+	flags.BoolVar(&jsonOutput, "json", false, "output in JSON format")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s inspect [options] filename:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	reader, err := passbook.OpenReader(flags.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading passbook file:", err)
+	}
+	info := newInspection(reader)
+	if jsonOutput {
+		if err := info.printJSON(os.Stdout); err != nil {
+			log.Fatalln("Error encoding JSON:", err)
+		}
+		return
+	}
+	info.print(os.Stdout)
+}
+
+// newInspection собирает информацию о содержимом passbook-файла.
+func newInspection(reader *passbook.Reader) *inspection {
+	pass := reader.Pass
+	style, fields := pass.Style()
+	info := &inspection{
+		Style:              style,
+		PassTypeIdentifier: pass.PassTypeIdentifier,
+		TeamIdentifier:     pass.TeamIdentifier,
+		SerialNumber:       pass.SerialNumber,
+		OrganizationName:   pass.OrganizationName,
+		Description:        pass.Description,
+		Relevance: inspectionRelevance{
+			RelevantDates: pass.RelevantDates,
+			MaxDistance:   pass.MaxDistance,
+			Locations:     pass.Locations,
+			Beacons:       pass.Beacons,
+		},
+	}
+	if fields != nil {
+		for _, section := range []inspectionSection{
+			{"header", fields.Header},
+			{"primary", fields.Primary},
+			{"secondary", fields.Secondary},
+			{"auxiliary", fields.Auxiliary},
+			{"additional info", fields.AdditionalInfo},
+			{"back", fields.Back},
+		} {
+			if len(section.Fields) > 0 {
+				info.Sections = append(info.Sections, section)
+			}
+		}
+	}
+	// старые версии используют только barcode, новые — массив barcodes
+	info.Barcodes = pass.Barcodes
+	if len(info.Barcodes) == 0 && pass.Barcode != nil {
+		info.Barcodes = append(info.Barcodes, *pass.Barcode)
+	}
+	if compatibility, err := pass.Compatibility(); err == nil {
+		info.Compatibility = compatibility
+	}
+	if pass.RelevantDate != nil {
+		relevantDate := time.Time(*pass.RelevantDate)
+		info.Relevance.RelevantDate = &relevantDate
+	}
+	// картинки, включая локализованные
+	for _, name := range reader.Names() {
+		if path.Ext(name) != ".png" {
+			continue
+		}
+		data := reader.Files[name]
+		img := inspectionImage{Name: name, Size: len(data)}
+		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+			img.Error = err.Error()
+		} else {
+			img.Width, img.Height = config.Width, config.Height
+		}
+		info.Images = append(info.Images, img)
+	}
+	// локализации
+	for _, lang := range reader.Localizations() {
+		language := inspectionLanguage{Language: lang}
+		prefix := lang + ".lproj/"
+		for _, name := range reader.Names() {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			language.Files = append(language.Files, strings.TrimPrefix(name, prefix))
+			if path.Ext(name) == ".strings" {
+				if localized, err := passbook.ParseStrings(reader.Files[name]); err == nil {
+					language.Strings += len(localized)
+				}
+			}
+		}
+		info.Localizations = append(info.Localizations, language)
+	}
+	// сертификат, которым подписан passbook
+	if cert, err := reader.Certificate(); err != nil {
+		info.CertificateError = err.Error()
+	} else {
+		info.Certificate = &inspectionCertificate{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter,
+			Expired:  time.Now().After(cert.NotAfter),
+		}
+	}
+	return info
+}
+
+// printJSON выводит информацию в формате JSON.
+func (info *inspection) printJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(info)
+}
+
+// print выводит информацию в читаемом виде.
+func (info *inspection) print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "Style:\t%s\n", info.Style)
+	fmt.Fprintf(w, "Pass type:\t%s\n", info.PassTypeIdentifier)
+	fmt.Fprintf(w, "Team:\t%s\n", info.TeamIdentifier)
+	fmt.Fprintf(w, "Serial number:\t%s\n", info.SerialNumber)
+	fmt.Fprintf(w, "Organization:\t%s\n", info.OrganizationName)
+	fmt.Fprintf(w, "Description:\t%s\n", info.Description)
+	for _, section := range info.Sections {
+		fmt.Fprintf(w, "\n%s fields:\n", strings.ToUpper(section.Name[:1])+section.Name[1:])
+		for _, field := range section.Fields {
+			fmt.Fprintf(w, "  %s\t%s\t%v\n", field.Key, field.Label, field.Value)
+		}
+	}
+	if len(info.Barcodes) > 0 {
+		fmt.Fprintln(w, "\nBarcodes:")
+		for _, barcode := range info.Barcodes {
+			fmt.Fprintf(w, "  %s\t%s\t%q\n", barcode.Format, barcode.MessageEncoding, barcode.Message)
+		}
+	}
+	relevance := info.Relevance
+	if relevance.RelevantDate != nil || len(relevance.RelevantDates) > 0 ||
+		len(relevance.Locations) > 0 || len(relevance.Beacons) > 0 {
+		fmt.Fprintln(w, "\nRelevance:")
+		if relevance.RelevantDate != nil {
+			fmt.Fprintf(w, "  date\t%s\n", relevance.RelevantDate.Format(time.RFC3339))
+		}
+		for _, date := range relevance.RelevantDates {
+			if date.Date != nil {
+				fmt.Fprintf(w, "  date\t%s\n", time.Time(*date.Date).Format(time.RFC3339))
+				continue
+			}
+			var start, end string
+			if date.StartDate != nil {
+				start = time.Time(*date.StartDate).Format(time.RFC3339)
+			}
+			if date.EndDate != nil {
+				end = time.Time(*date.EndDate).Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "  interval\t%s – %s\n", start, end)
+		}
+		if relevance.MaxDistance > 0 {
+			fmt.Fprintf(w, "  max distance\t%d m\n", relevance.MaxDistance)
+		}
+		for _, location := range relevance.Locations {
+			fmt.Fprintf(w, "  location\t%g, %g\t%s\n", location.Latitude, location.Longitude, location.RelevantText)
+		}
+		for _, beacon := range relevance.Beacons {
+			fmt.Fprintf(w, "  beacon\t%s %d/%d\t%s\n", beacon.ProximityUUID, beacon.Major, beacon.Minor, beacon.RelevantText)
+		}
+	}
+	if info.Compatibility != nil {
+		fmt.Fprintln(w, "\nCompatibility:")
+		fmt.Fprintf(w, "  minimum\tiOS %s, watchOS %s\n", info.Compatibility.IOS, info.Compatibility.WatchOS)
+		fmt.Fprintf(w, "  all keys\tiOS %s, watchOS %s\n", info.Compatibility.FullIOS, info.Compatibility.FullWatchOS)
+		for _, key := range info.Compatibility.Keys {
+			degrades := ""
+			if key.Degrades {
+				degrades = "ignored by older versions"
+			}
+			fmt.Fprintf(w, "  %s\tiOS %s, watchOS %s\t%s\n", key.Key, key.IOS, key.WatchOS, degrades)
+		}
+	}
+	if len(info.Images) > 0 {
+		fmt.Fprintln(w, "\nImages:")
+		for _, img := range info.Images {
+			if img.Error != "" {
+				fmt.Fprintf(w, "  %s\t%s\t%d bytes\n", img.Name, img.Error, img.Size)
+				continue
+			}
+			fmt.Fprintf(w, "  %s\t%dx%d\t%d bytes\n", img.Name, img.Width, img.Height, img.Size)
+		}
+	}
+	if len(info.Localizations) > 0 {
+		fmt.Fprintln(w, "\nLocalizations:")
+		for _, language := range info.Localizations {
+			fmt.Fprintf(w, "  %s\t%d strings\t%s\n", language.Language, language.Strings,
+				strings.Join(language.Files, ", "))
+		}
+	}
+	fmt.Fprintln(w, "\nCertificate:")
+	if info.Certificate == nil {
+		fmt.Fprintf(w, "  error\t%s\n", info.CertificateError)
+		return
+	}
+	fmt.Fprintf(w, "  subject\t%s\n", info.Certificate.Subject)
+	fmt.Fprintf(w, "  issuer\t%s\n", info.Certificate.Issuer)
+	expires := info.Certificate.NotAfter.Format(time.RFC3339)
+	if info.Certificate.Expired {
+		expires += " (expired)"
+	}
+	fmt.Fprintf(w, "  expires\t%s\n", expires)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/passbooktest"
+)
+
+var update = flag.Bool("update", false, "update golden files of inspect output")
+
+// inspectPassbook возвращает passbook-файл, информация о котором не зависит
+// от времени запуска теста: сертификат выдан на фиксированный срок.
+func inspectPassbook(t *testing.T) *passbook.Reader {
+	t.Helper()
+	ca, err := passbooktest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := ca.NewIdentityValidity("pass.com.example.event", "A1B2C3D4E5",
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	icon := func(size int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, size, size))); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	relevantDate := passbook.W3Time(time.Date(2031, 7, 10, 19, 0, 0, 0, time.UTC))
+	data := passbooktest.Build(t, &passbook.Package{
+		Pass: passbook.Pass{
+			SerialNumber:     "E-1024",
+			OrganizationName: "Example Arena",
+			Description:      "Concert ticket",
+			RelevantDate:     &relevantDate,
+			MaxDistance:      500,
+			Locations:        []passbook.Location{{Latitude: 55.7558, Longitude: 37.6173, RelevantText: "Arena"}},
+			Beacons:          []passbook.Beacon{{ProximityUUID: "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", Major: 1, Minor: 2, RelevantText: "Entrance"}},
+			Barcode:          &passbook.Barcode{Format: passbook.PKBarcodeFormatQR, Message: "E-1024", MessageEncoding: "utf-8"},
+			EventTicket: &passbook.Fields{
+				Header:  passbook.FieldsData{{Key: "row", Label: "ROW", Value: 12}},
+				Primary: passbook.FieldsData{{Key: "event", Label: "EVENT", Value: "Concert"}},
+				Back:    passbook.FieldsData{{Key: "terms", Label: "TERMS", Value: "No refunds"}},
+			},
+		},
+		Images: map[string][]byte{"icon.png": icon(29), "icon@2x.png": icon(58), "logo.png": []byte("logo")},
+		Localizations: map[string]passbook.Localization{
+			"ru": {Strings: map[string]string{"EVENT": "СОБЫТИЕ", "TERMS": "УСЛОВИЯ"},
+				Images: map[string][]byte{"logo.png": icon(50)}},
+		},
+		ModTime: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, identity)
+	return passbooktest.Open(t, data)
+}
+
+// checkGolden сравнивает вывод с эталонным файлом или обновляет его с -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	filename := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(filename, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestInspectText(t *testing.T) {
+	var buf bytes.Buffer
+	newInspection(inspectPassbook(t)).print(&buf)
+	checkGolden(t, "inspect.txt", buf.Bytes())
+}
+
+func TestInspectJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newInspection(inspectPassbook(t)).printJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "inspect.json", buf.Bytes())
+}
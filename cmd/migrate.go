@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mdigger/commitfile"
+	"github.com/mdigger/passbook"
+)
+
+// migrate обновляет устаревшие ключи описания passbook и выводит список
+// изменений. Описание читается из pass.json или из passbook-файла.
+func migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var outFilename string
+	flags.StringVar(&outFilename, "out", "", "output file for migrated pass.json (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s migrate [options] pass.json|filename.pkpass|dir:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	data, changes, err := migratePass(loadPass(flags.Arg(0)))
+	for _, change := range changes {
+		log.Println(change)
+	}
+	if len(changes) == 0 {
+		log.Println("Nothing to migrate")
+	}
+	if err != nil {
+		log.Fatalln("Error encoding pass description:", err)
+	}
+	if outFilename == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	file, err := commitfile.Create(outFilename)
+	if err != nil {
+		log.Fatalln("Error creating file:", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		log.Fatalln("Error writing file:", err)
+	}
+	file.Commit()
+	if err := file.Close(); err != nil {
+		log.Fatalln("Error closing file:", err)
+	}
+}
+
+// migratePass обновляет устаревшие ключи описания passbook и возвращает
+// его в формате JSON с отступами и список изменений.
+func migratePass(pass *passbook.Pass) ([]byte, []string, error) {
+	changes := pass.Migrate()
+	data, err := pass.Marshal()
+	if err != nil {
+		return nil, changes, err
+	}
+	var buf bytes.Buffer
+	json.Indent(&buf, data, "", "  ")
+	buf.WriteByte('\n')
+	return buf.Bytes(), changes, nil
+}
+
+// loadPass загружает описание passbook из pass.json, из passbook-файла или
+// из каталога с pass.json. В случае ошибки приложение завершается.
+func loadPass(filename string) *passbook.Pass {
+	pass, _ := loadPassFiles(filename)
+	return pass
+}
+
+// loadPassFiles загружает описание и файлы passbook из passbook-файла или из
+// каталога с pass.json. Для pass.json возвращается только само описание.
+// В случае ошибки приложение завершается.
+func loadPassFiles(name string) (*passbook.Pass, map[string][]byte) {
+	info, err := os.Stat(name)
+	if err != nil {
+		log.Fatalln("Error reading pass:", err)
+	}
+	if !info.IsDir() && filepath.Ext(name) == ".pkpass" {
+		reader, err := passbook.OpenReader(name)
+		if err != nil {
+			log.Fatalln("Error reading passbook file:", err)
+		}
+		return reader.Pass, reader.Files
+	}
+	files := make(map[string][]byte)
+	if !info.IsDir() {
+		if files["pass.json"], err = os.ReadFile(name); err != nil {
+			log.Fatalln("Error reading pass description:", err)
+		}
+	} else if err := filepath.Walk(name, func(filename string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch filepath.Ext(filename) {
+		case ".json", ".png", ".strings":
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(name, filename)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filename)
+		files[filepath.ToSlash(rel)] = data
+		return err
+	}); err != nil {
+		log.Fatalln("Error reading pass directory:", err)
+	}
+	pass := new(passbook.Pass)
+	if err := json.Unmarshal(files["pass.json"], pass); err != nil {
+		log.Fatalln("Error parsing pass description:", err)
+	}
+	return pass, files
+}
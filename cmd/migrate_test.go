@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdigger/passbook"
+)
+
+// legacyPass — описание passbook в старом формате без веб-сервиса.
+const legacyPass = `{
+  "formatVersion": 1,
+  "passTypeIdentifier": "pass.com.example.test",
+  "serialNumber": "1",
+  "teamIdentifier": "A93A5CM278",
+  "organizationName": "Example Inc.",
+  "description": "Boarding pass",
+  "relevantDate": "2031-12-08T13:00Z",
+  "barcode": {"format": "PKBarcodeFormatPDF417", "message": "123456", "messageEncoding": "iso-8859-1"},
+  "boardingPass": {
+    "transitType": "PKTransitTypeAir",
+    "headerFields": [{"key": "gate", "label": "GATE", "value": "23"}],
+    "primaryFields": [
+      {"key": "depart", "label": "SAN FRANCISCO", "value": "SFO"},
+      {"key": "arrive", "label": "NEW YORK", "value": "JFK"}
+    ]
+  }
+}`
+
+func TestMigratePass(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "pass.json")
+	if err := os.WriteFile(filename, []byte(legacyPass), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, changes, err := migratePass(loadPass(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) == 0 {
+		t.Error("no changes")
+	}
+	var pass passbook.Pass
+	if err := json.Unmarshal(data, &pass); err != nil {
+		t.Fatal(err)
+	}
+	if err := pass.Validate(); err != nil {
+		t.Error(err)
+	}
+	// устаревшие ключи сохраняются для старых устройств
+	if pass.Barcode == nil || len(pass.Barcodes) != 1 || pass.RelevantDate == nil ||
+		len(pass.RelevantDates) != 1 {
+		t.Errorf("barcodes and relevant dates are not migrated:\n%s", data)
+	}
+	if pass.Semantics == nil || pass.Semantics.DepartureGate != "23" {
+		t.Errorf("semantics are not derived:\n%s", data)
+	}
+	if pass.AuthenticationToken != "" || pass.WebServiceURL != "" {
+		t.Errorf("web service is added:\n%s", data)
+	}
+	// повторная миграция ничего не меняет
+	if _, changes, err := migratePass(&pass); err != nil || len(changes) != 0 {
+		t.Errorf("repeated migration: %v, %v", changes, err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path"
 	"path/filepath"
 
 	"github.com/mdigger/commitfile"
@@ -13,8 +12,28 @@ import (
 	"github.com/mdigger/pkcs7sign"
 )
 
+// команды приложения, помимо создания одного passbook
+var commands = map[string]func(args []string){
+	"batch":     batch,
+	"inspect":   inspect,
+	"verify":    verify,
+	"csr":       csr,
+	"export":    export,
+	"expiry":    expiry,
+	"migrate":   migrate,
+	"preview":   previewPass,
+	"relevance": relevance,
+}
+
 func main() {
 	log.SetFlags(0)
+	// выполняем команду, если она указана первым аргументом
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
 	// инициализируем параметры для приложения
 	var certFilename, privFilename, passwd string
 	flag.StringVar(&certFilename, "cert", "cert.cer", "file with x509 Certificate")
@@ -25,23 +44,22 @@ func main() {
 			"Usage of %s [options] filename [dir with files]:\nOptions:\n",
 			os.Args[0])
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "Commands:\n"+
+			"  batch\tcreate passbook files from template and data file\n"+
+			"  inspect\tprint the content of passbook file\n"+
+			"  verify\tcheck passbook file\n"+
+			"  csr\tcreate private key and certificate signing request\n"+
+			"  export\tsave certificate with private key as PEM or PKCS #12\n"+
+			"  expiry\treport days until certificates expire\n"+
+			"  migrate\tupgrade legacy keys of pass.json\n"+
+			"  preview\tshow pass in terminal or save it as image\n"+
+			"  relevance\tcheck if pass is relevant at given time and place\n")
 	}
 	flag.Parse()
 	if flag.NArg() < 1 {
 		log.Fatal("Empty filename for passbook file")
 	}
-	// загружаем сертификат для подписи
-	log.Printf("Loading sertificate %q", certFilename)
-	cert, err := pkcs7.LoadCertificate(certFilename)
-	if err != nil {
-		log.Fatalln("Error reading certificate file:", err)
-	}
-	// загружаем приватный ключ для подписи
-	log.Printf("Loading private key %q", privFilename)
-	priv, err := pkcs7.LoadPKCS1PrivateKeyPEM(privFilename, passwd)
-	if err != nil {
-		log.Fatalln("Error reading private key:", err)
-	}
+	identity := loadIdentity(certFilename, privFilename, passwd)
 	// получаем имя результирующего файла с passbook
 	filename := flag.Arg(0)
 	if filepath.Ext(filename) != ".pkpass" {
@@ -54,15 +72,34 @@ func main() {
 		log.Fatalln("Error creating passbook file:", err)
 	}
 	// инициализируем создание passbook
-	passbookWrite := passbook.NewWriter(passbookFile, cert, priv)
+	passbookWrite := passbook.NewSignerWriter(passbookFile, identity)
 	// инициализируем путь до исходных файлов
 	base := flag.Arg(1) // второй аргумент в параметрах
 	if base == "" {
 		base = "."
 	}
 	// log.Printf("Working dir is %q", base)
+	if err := addFiles(passbookWrite, base); err != nil {
+		passbookFile.Close()
+		log.Fatalln("Error adding file:", err)
+	}
+	// завершаем формирование passbook
+	if err := passbookWrite.Close(); err != nil {
+		passbookFile.Close()
+		log.Fatalln("Error signing:", err)
+	}
+	passbookFile.Commit() // подтверждаем успешное создание
+	if err := passbookFile.Close(); err != nil {
+		log.Fatalln("Error closing file:", err)
+	}
+	log.Printf("Passbook file %q created\n", filename)
+}
+
+// addFiles добавляет в passbook все файлы из указанного каталога. Какие из них
+// попадут в passbook, решает Writer.Add: остальные файлы игнорируются.
+func addFiles(passbookWrite *passbook.Writer, base string) error {
 	// перебираем все файлы в указанном каталоге
-	if err := filepath.Walk(base, func(filename string, info os.FileInfo, err error) error {
+	return filepath.Walk(base, func(filename string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -73,16 +110,6 @@ func main() {
 		if err != nil {
 			return err
 		}
-		switch path.Ext(name) {
-		case ".json": // из json-файлов добавляем только непосредственно описание
-			if name != "pass.json" {
-				return nil
-			}
-		case ".png": // картинки
-		case ".strings": // локализация
-		default: // все остальное игнорируем
-			return nil
-		}
 		log.Printf("Adding %q", name)
 		file, err := os.Open(filename) // открываем файл для чтения
 		if err != nil {
@@ -92,18 +119,27 @@ func main() {
 		err = passbookWrite.Add(filepath.ToSlash(name), file)
 		file.Close()
 		return err
-	}); err != nil {
-		passbookFile.Close()
-		log.Fatalln("Error adding file:", err)
+	})
+}
+
+// loadIdentity загружает сертификат и приватный ключ для подписи.
+// В случае ошибки приложение завершается.
+func loadIdentity(certFilename, privFilename, passwd string) *passbook.Identity {
+	// загружаем сертификат для подписи
+	log.Printf("Loading sertificate %q", certFilename)
+	cert, err := pkcs7.LoadCertificate(certFilename)
+	if err != nil {
+		log.Fatalln("Error reading certificate file:", err)
 	}
-	// завершаем формирование passbook
-	if err := passbookWrite.Close(); err != nil {
-		passbookFile.Close()
-		log.Fatalln("Error signing:", err)
+	// загружаем приватный ключ для подписи
+	log.Printf("Loading private key %q", privFilename)
+	priv, err := pkcs7.LoadPKCS1PrivateKeyPEM(privFilename, passwd)
+	if err != nil {
+		log.Fatalln("Error reading private key:", err)
 	}
-	passbookFile.Commit() // подтверждаем успешное создание
-	if err := passbookFile.Close(); err != nil {
-		log.Fatalln("Error closing file:", err)
+	identity, err := passbook.NewIdentity(cert, priv)
+	if err != nil {
+		log.Fatalln("Error loading identity:", err)
 	}
-	log.Printf("Passbook file %q created\n", filename)
+	return identity
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/passbooktest"
+)
+
+func TestAddFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"pass.json": `{"formatVersion": 1, "serialNumber": "1", "organizationName": "Example",
+			"description": "Store card", "storeCard": {}}`,
+		"personalization.json":    `{"requiredPersonalizationFields": ["PKPassPersonalizationFieldName"], "description": "Join"}`,
+		"icon.png":                "icon",
+		"personalizationLogo.png": "logo",
+		"en.lproj/pass.strings":   `"title" = "Card";`,
+		"template.json":           "{}",
+		"readme.txt":              "ignored",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	identity, _ := passbooktest.NewIdentity(t, "pass.com.example.test", "A1B2C3D4E5")
+	var buf bytes.Buffer
+	w := passbook.NewSignerWriter(&buf, identity)
+	if err := addFiles(w, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader := passbooktest.Open(t, buf.Bytes())
+	for _, name := range []string{"pass.json", "personalization.json", "icon.png",
+		"personalizationLogo.png", "en.lproj/pass.strings"} {
+		if _, ok := reader.Manifest[name]; !ok {
+			t.Errorf("%s is not in manifest", name)
+		}
+		if _, ok := reader.Files[name]; !ok {
+			t.Errorf("%s is not in archive", name)
+		}
+	}
+	for _, name := range []string{"template.json", "readme.txt"} {
+		if _, ok := reader.Files[name]; ok {
+			t.Errorf("%s is added", name)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/internal/format"
+	"github.com/mdigger/passbook/internal/wallet"
+	"github.com/mdigger/passbook/preview"
+)
+
+// cardWidth — ширина изображения passbook в терминале в символах.
+const cardWidth = 48
+
+// previewPass выводит изображение passbook в терминале или сохраняет его в
+// PNG или SVG. Штрих-код рисуется символами полублоков, так что его можно
+// отсканировать с экрана.
+func previewPass(args []string) {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	var back, noColor bool
+	var lang, outFilename string
+	flags.BoolVar(&back, "back", false, "show the back of the pass")
+	flags.StringVar(&lang, "lang", "", "language of localized strings and images, e.g. en")
+	flags.StringVar(&outFilename, "out", "", "save the preview as .png or .svg file or printable ticket as .pdf file")
+	flags.BoolVar(&noColor, "nocolor", os.Getenv("NO_COLOR") != "", "print without colors")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s preview [options] filename.pkpass|dir:\nOptions:\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	pass, files := loadPassFiles(flags.Arg(0))
+	opts := &preview.Options{Files: files, Language: lang}
+	if back {
+		opts.Side = preview.Back
+	}
+	if outFilename != "" {
+		savePreview(outFilename, pass, opts)
+		return
+	}
+	out := bufio.NewWriter(os.Stdout)
+	t := &terminal{out: out, pass: pass, strings: opts.LocalizedStrings(), color: !noColor}
+	if back {
+		t.back()
+	} else {
+		t.front()
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatalln("Error writing preview:", err)
+	}
+}
+
+// savePreview сохраняет изображение passbook в PNG или SVG или билет для
+// печати в PDF в зависимости от расширения имени файла.
+func savePreview(filename string, pass *passbook.Pass, opts *preview.Options) {
+	write := preview.WritePNG
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".png":
+	case ".svg":
+		write = preview.WriteSVG
+	case ".pdf":
+		write = preview.WritePDF
+	default:
+		log.Fatalf("Unsupported preview format %q", filepath.Ext(filename))
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Fatalln("Error creating file:", err)
+	}
+	if err := write(file, pass, opts); err != nil {
+		file.Close()
+		log.Fatalln("Error rendering preview:", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalln("Error closing file:", err)
+	}
+	log.Printf("Preview saved to %q", filename)
+}
+
+// terminal выводит passbook в терминал.
+type terminal struct {
+	out     io.Writer
+	pass    *passbook.Pass
+	strings map[string]string
+	color   bool
+}
+
+// ANSI-последовательности для оформления текста.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiBarcode = "\x1b[30;107m" // черный текст на белом фоне
+)
+
+// colors возвращает ANSI-последовательность для цвета текста и фона.
+func (t *terminal) colors(fg, bg *passbook.Color) string {
+	if !t.color {
+		return ""
+	}
+	var s string
+	if fg != nil {
+		s += fmt.Sprintf("\x1b[38;2;%d;%d;%dm", fg.R, fg.G, fg.B)
+	}
+	if bg != nil {
+		s += fmt.Sprintf("\x1b[48;2;%d;%d;%dm", bg.R, bg.G, bg.B)
+	}
+	return s
+}
+
+// line выводит строку passbook шириной cardWidth в цветах passbook.
+func (t *terminal) line(text string, fg *passbook.Color, bold bool) {
+	style := t.colors(fg, t.pass.BackgroundColor)
+	if bold && t.color {
+		style += ansiBold
+	}
+	reset := ""
+	if style != "" {
+		reset = ansiReset
+	}
+	fmt.Fprintf(t.out, "%s %s %s\n", style, pad(text, cardWidth-2, false), reset)
+}
+
+// pad обрезает или дополняет пробелами строку до указанной ширины.
+func pad(text string, width int, right bool) string {
+	n := utf8.RuneCountInString(text)
+	if width <= 0 {
+		return ""
+	}
+	if n > width {
+		runes := []rune(text)
+		return string(runes[:width-1]) + "…"
+	}
+	spaces := strings.Repeat(" ", width-n)
+	if right {
+		return spaces + text
+	}
+	return text + spaces
+}
+
+// labelColor возвращает цвет подписей полей.
+func (t *terminal) labelColor() *passbook.Color {
+	if t.pass.LabelColor != nil {
+		return t.pass.LabelColor
+	}
+	return t.pass.ForegroundColor
+}
+
+// front выводит лицевую сторону passbook.
+func (t *terminal) front() {
+	style, fields := t.pass.Style()
+	if fields == nil {
+		log.Fatalln("Unknown pass style")
+	}
+	// в заголовке текст логотипа и поля заголовка справа
+	var headerLabels, headerValues []string
+	for _, field := range fields.Header {
+		label, value := field.Display(t.strings)
+		width := utf8.RuneCountInString(label)
+		if n := utf8.RuneCountInString(value); n > width {
+			width = n
+		}
+		headerLabels = append(headerLabels, pad(label, width, true))
+		headerValues = append(headerValues, pad(value, width, true))
+	}
+	labels, values := strings.Join(headerLabels, "  "), strings.Join(headerValues, "  ")
+	logoText := format.Localize(t.strings, t.pass.LogoText)
+	logoWidth := cardWidth - 2 - utf8.RuneCountInString(values) - 2
+	if logoWidth < 1 {
+		logoWidth = 1
+	}
+	t.line("", nil, false)
+	t.line(pad("", logoWidth, false)+"  "+labels, t.labelColor(), false)
+	t.line(pad(logoText, logoWidth, false)+"  "+values, t.pass.ForegroundColor, true)
+	t.line("", nil, false)
+	t.columns(fields.Primary, true)
+	// вторичные и дополнительные поля выводятся так же, как в изображении
+	for _, row := range wallet.FrontRows(style, fields) {
+		t.columns(row, false)
+	}
+	t.barcode()
+}
+
+// columns выводит поля раздела в колонках одинаковой ширины.
+func (t *terminal) columns(fields passbook.FieldsData, bold bool) {
+	if len(fields) == 0 {
+		return
+	}
+	width := (cardWidth - 2 - 2*(len(fields)-1)) / len(fields)
+	var labels, values []string
+	for i, field := range fields {
+		label, value := field.Display(t.strings)
+		right := field.TextAlignment == passbook.PKTextAlignmentRight ||
+			(field.TextAlignment == "" && i > 0 && i == len(fields)-1)
+		labels = append(labels, pad(label, width, right))
+		values = append(values, pad(value, width, right))
+	}
+	t.line(strings.Join(labels, "  "), t.labelColor(), false)
+	t.line(strings.Join(values, "  "), t.pass.ForegroundColor, bold)
+	t.line("", nil, false)
+}
+
+// barcode выводит штрих-код полублоками: каждый символ отображает два модуля
+// по вертикали. С цветами штрих-код выводится черным на белом с тихой зоной,
+// иначе в светлом на темном терминале он не сканируется; без цветов выводятся
+// только символы блоков.
+func (t *terminal) barcode() {
+	barcode := t.pass.DisplayedBarcode()
+	if barcode == nil {
+		return
+	}
+	matrix, err := barcode.Matrix(passbook.ErrorCorrectionMedium)
+	if err != nil {
+		log.Fatalln("Error encoding barcode:", err)
+	}
+	quiet := matrix.QuietZone
+	height := matrix.Height
+	if matrix.Linear {
+		height = 10 // линейный штрих-код растягивается по вертикали
+	}
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		if matrix.Linear && y >= 0 && y < height {
+			y = 0
+		}
+		return matrix.At(x, y)
+	}
+	width := matrix.Width + 2*quiet
+	for y := 0; y < height+2*quiet; y += 2 {
+		var line strings.Builder
+		for x := 0; x < width; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				line.WriteRune('█')
+			case top:
+				line.WriteRune('▀')
+			case bottom:
+				line.WriteRune('▄')
+			default:
+				line.WriteByte(' ')
+			}
+		}
+		if t.color {
+			fmt.Fprintf(t.out, "%s%s%s\n", ansiBarcode, line.String(), ansiReset)
+		} else {
+			fmt.Fprintln(t.out, line.String())
+		}
+	}
+	if altText := format.Localize(t.strings, barcode.AltText); altText != "" {
+		fmt.Fprintln(t.out, altText)
+	}
+}
+
+// back выводит обратную сторону passbook: поля друг под другом, значения
+// переносятся по словам.
+func (t *terminal) back() {
+	_, fields := t.pass.Style()
+	if fields == nil {
+		log.Fatalln("Unknown pass style")
+	}
+	bold := ""
+	if t.color {
+		bold = ansiBold
+	}
+	reset := ""
+	if t.color {
+		reset = ansiReset
+	}
+	fmt.Fprintf(t.out, "%s%s%s\n%s\n", bold, t.pass.OrganizationName, reset, t.pass.Description)
+	for _, field := range fields.Back {
+		label, value := field.Display(t.strings)
+		fmt.Fprintln(t.out, strings.Repeat("─", cardWidth))
+		if label != "" {
+			fmt.Fprintf(t.out, "%s%s%s\n", bold, label, reset)
+		}
+		for _, line := range wallet.Wrap(value, cardWidth, runeWidth) {
+			fmt.Fprintln(t.out, line)
+		}
+	}
+}
+
+// runeWidth возвращает ширину текста в терминале в символах.
+func runeWidth(text string) float64 {
+	return float64(utf8.RuneCountInString(text))
+}
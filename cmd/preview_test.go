@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mdigger/passbook"
+)
+
+func TestTerminal(t *testing.T) {
+	fields := make(passbook.FieldsData, 6)
+	for i := range fields {
+		fields[i] = passbook.Field{Key: string(rune('a' + i)), Label: "L" + string(rune('a'+i)), Value: "v"}
+	}
+	pass := &passbook.Pass{
+		LogoText:        "logo",
+		ForegroundColor: &passbook.Color{R: 0xff, G: 0xff, B: 0xff},
+		BackgroundColor: &passbook.Color{R: 0x30, G: 0x30, B: 0x30},
+		Generic:         &passbook.Fields{Secondary: fields},
+		Barcodes:        []passbook.Barcode{{Format: passbook.PKBarcodeFormatQR, Message: "123", AltText: "alt"}},
+	}
+	for _, color := range []bool{true, false} {
+		var buf bytes.Buffer
+		term := &terminal{out: &buf, pass: pass, strings: map[string]string{"logo": "Логотип", "alt": "Текст"}, color: color}
+		term.front()
+		out := buf.String()
+		// штрих-код выводится черным на белом только с цветами
+		if strings.Contains(out, ansiBarcode+" ") != color {
+			t.Errorf("color %v: wrong colors of barcode", color)
+		}
+		if !color && strings.Contains(out, "\x1b[") {
+			t.Errorf("escape sequences without colors:\n%q", out)
+		}
+		if !strings.Contains(out, "█") {
+			t.Errorf("color %v: barcode is not printed:\n%s", color, out)
+		}
+		if strings.Contains(out, "\x1b[38;2") != color {
+			t.Errorf("color %v: colors of the pass are used wrong", color)
+		}
+		// выводятся только 4 вторичных поля
+		if !strings.Contains(out, "Ld") || strings.Contains(out, "Le") {
+			t.Errorf("color %v: wrong secondary fields:\n%s", color, out)
+		}
+		if !strings.Contains(out, "Логотип") || !strings.Contains(out, "Текст") {
+			t.Errorf("color %v: strings are not localized:\n%s", color, out)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdigger/passbook"
+)
+
+// exitNotRelevant — код завершения, если passbook не актуален.
+const exitNotRelevant = 3
+
+// beaconsFlag описывает маяки рядом с устройством в виде UUID[:major[:minor]].
+type beaconsFlag []passbook.Beacon
+
+func (b *beaconsFlag) String() string {
+	var list []string
+	for _, beacon := range *b {
+		list = append(list, fmt.Sprintf("%s:%d:%d", beacon.ProximityUUID, beacon.Major, beacon.Minor))
+	}
+	return strings.Join(list, ", ")
+}
+
+func (b *beaconsFlag) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return fmt.Errorf("bad beacon %q", value)
+	}
+	beacon := passbook.Beacon{ProximityUUID: parts[0]}
+	for i, id := range []*uint16{&beacon.Major, &beacon.Minor} {
+		if len(parts) <= i+1 {
+			break
+		}
+		n, err := strconv.ParseUint(parts[i+1], 10, 16)
+		if err != nil {
+			return fmt.Errorf("bad beacon %q: %v", value, err)
+		}
+		*id = uint16(n)
+	}
+	*b = append(*b, beacon)
+	return nil
+}
+
+// relevance проверяет, будет ли passbook актуален на устройстве в указанное
+// время, в указанном месте и рядом с указанными маяками.
+func relevance(args []string) {
+	flags := flag.NewFlagSet("relevance", flag.ExitOnError)
+	var timeValue, locationValue string
+	var beacons beaconsFlag
+	flags.StringVar(&timeValue, "time", "", "device time in RFC 3339 format (default now)")
+	flags.StringVar(&locationValue, "location", "", "device location as latitude,longitude")
+	flags.Var(&beacons, "beacon", "nearby beacon as UUID[:major[:minor]]; may be repeated")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s relevance [options] pass.json|filename.pkpass|dir:\n"+
+				"Exit status is %d if the pass is not relevant.\n"+
+				"Options:\n",
+			os.Args[0], exitNotRelevant)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	state := passbook.DeviceState{Time: time.Now(), Beacons: beacons}
+	if timeValue != "" {
+		t, err := time.Parse(time.RFC3339, timeValue)
+		if err != nil {
+			log.Fatalln("Error parsing time:", err)
+		}
+		state.Time = t
+	}
+	if locationValue != "" {
+		var location passbook.Location
+		latitude, longitude, _ := strings.Cut(locationValue, ",")
+		var err error
+		if location.Latitude, err = strconv.ParseFloat(strings.TrimSpace(latitude), 64); err == nil {
+			location.Longitude, err = strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+		}
+		if err != nil {
+			log.Fatalln("Error parsing location:", err)
+		}
+		state.Location = &location
+	}
+	pass := loadPass(flags.Arg(0))
+	result := pass.Relevance(state)
+	if !result.Relevant {
+		fmt.Println("Not relevant:", result.Reason)
+		os.Exit(exitNotRelevant)
+	}
+	fmt.Println("Relevant:", result.Reason)
+	if result.Location != nil && result.Location.RelevantText != "" {
+		fmt.Println("Text:", result.Location.RelevantText)
+	}
+	if result.Beacon != nil && result.Beacon.RelevantText != "" {
+		fmt.Println("Text:", result.Beacon.RelevantText)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mdigger/passbook"
+)
+
+// коды завершения команды проверки
+const (
+	exitInvalid  = 1 // passbook содержит ошибки
+	exitWarnings = 3 // passbook содержит только предупреждения
+)
+
+// verify проверяет passbook-файл и выводит найденные проблемы.
+func verify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	var rootsFilename, wwdrFilename string
+	flags.StringVar(&rootsFilename, "roots", "", "file with trusted root Certificates, such as Apple Root CA")
+	flags.StringVar(&wwdrFilename, "wwdr", "", "file with Apple WWDR intermediate Certificate")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage of %s verify [options] filename:\n"+
+				"Exit status is %d if the pass is invalid and %d if there are only warnings.\n"+
+				"Options:\n",
+			os.Args[0], exitInvalid, exitWarnings)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var opts passbook.VerifyOptions
+	if rootsFilename != "" {
+		roots, err := loadCertPool(rootsFilename)
+		if err != nil {
+			log.Fatalln("Error reading root certificates:", err)
+		}
+		opts.Roots = roots
+	}
+	if wwdrFilename != "" {
+		intermediates, err := loadCertPool(wwdrFilename)
+		if err != nil {
+			log.Fatalln("Error reading intermediate certificates:", err)
+		}
+		opts.Intermediates = intermediates
+	}
+	reader, err := passbook.OpenReader(flags.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading passbook file:", err)
+	}
+	problems := reader.Verify(opts)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) == 0 {
+		fmt.Println("OK")
+	}
+	os.Exit(exitStatus(problems))
+}
+
+// exitStatus возвращает код завершения для найденных проблем: ошибки
+// важнее предупреждений.
+func exitStatus(problems []passbook.Problem) int {
+	status := 0
+	for _, problem := range problems {
+		if problem.Severity == passbook.SeverityError {
+			return exitInvalid
+		}
+		status = exitWarnings
+	}
+	return status
+}
+
+// loadCertPool загружает сертификаты из файла в формате PEM или DER.
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// parseCertificates разбирает сертификаты в формате PEM или DER.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if certs != nil {
+		return certs, nil
+	}
+	certs, err := x509.ParseCertificates(data) // DER
+	if err == nil && len(certs) == 0 {
+		err = errors.New("no certificates found")
+	}
+	return certs, err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/passbooktest"
+)
+
+func TestExitStatus(t *testing.T) {
+	warning := passbook.Problem{Severity: passbook.SeverityWarning, Message: "logo.png missed"}
+	failure := passbook.Problem{Severity: passbook.SeverityError, Message: "signature missed"}
+	for _, test := range []struct {
+		name     string
+		problems []passbook.Problem
+		status   int
+	}{
+		{"no problems", nil, 0},
+		{"warnings", []passbook.Problem{warning, warning}, exitWarnings},
+		{"errors", []passbook.Problem{failure}, exitInvalid},
+		{"warning and error", []passbook.Problem{warning, failure}, exitInvalid},
+		{"error and warning", []passbook.Problem{failure, warning}, exitInvalid},
+	} {
+		if status := exitStatus(test.problems); status != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, status, test.status)
+		}
+	}
+}
+
+// TestVerifyWithoutWebService checks that the valid pass without web service
+// and authentication token passes the verification.
+func TestVerifyWithoutWebService(t *testing.T) {
+	identity, ca := passbooktest.NewIdentity(t, "pass.com.example.test", "A1B2C3D4E5")
+	data := passbooktest.Build(t, &passbook.Package{
+		Pass: passbook.Pass{
+			SerialNumber:     "1",
+			OrganizationName: "Example",
+			Description:      "Test pass",
+			Generic:          &passbook.Fields{},
+		},
+		Images: map[string][]byte{"icon.png": []byte("icon"), "icon@2x.png": []byte("icon"),
+			"logo.png": []byte("logo")},
+	}, identity)
+	reader := passbooktest.Open(t, data)
+	if problems := reader.Verify(ca.VerifyOptions()); exitStatus(problems) != 0 {
+		t.Errorf("problems: %v", problems)
+	}
+}
@@ -0,0 +1,211 @@
+package passbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Version is the version of the operating system, such as iOS 9.0.
+type Version struct {
+	Major, Minor int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Less returns true if the version v is older than w.
+func (v Version) Less(w Version) bool {
+	return v.Major < w.Major || v.Major == w.Major && v.Minor < w.Minor
+}
+
+// KeyRequirement describes the key of the pass that requires newer versions of
+// iOS and watchOS than the first versions supporting passes.
+type KeyRequirement struct {
+	Key      string  `json:"key"`      // Path to the key in pass.json, such as "eventTicket.primaryFields[0].semantics".
+	IOS      Version `json:"ios"`      // Version of iOS supporting the key.
+	WatchOS  Version `json:"watchOS"`  // Version of watchOS supporting the key.
+	Degrades bool    `json:"degrades"` // Older versions ignore the key and the pass is still usable.
+}
+
+// Compatibility describes the versions of iOS and watchOS required by the pass.
+type Compatibility struct {
+	IOS         Version          `json:"ios"`            // Minimum version of iOS where the pass is usable.
+	WatchOS     Version          `json:"watchOS"`        // Minimum version of watchOS where the pass is usable.
+	FullIOS     Version          `json:"fullIOS"`        // Minimum version of iOS supporting all keys of the pass.
+	FullWatchOS Version          `json:"fullWatchOS"`    // Minimum version of watchOS supporting all keys of the pass.
+	Keys        []KeyRequirement `json:"keys,omitempty"` // Keys requiring newer versions, the newest first.
+}
+
+// keyVersion describes the versions supporting the key.
+type keyVersion struct {
+	ios, watchOS Version
+	// required returns true if the pass is not usable on older versions,
+	// which ignore the key; if nil, the pass degrades gracefully.
+	required func(p Pass) bool
+}
+
+// require returns the versions of the key without which the pass is not usable.
+func (v keyVersion) require(required func(p Pass) bool) keyVersion {
+	v.required = required
+	return v
+}
+
+// always is the requirement of the keys that the pass can't do without, such
+// as NFC used for payment or loyalty at the terminal.
+func always(Pass) bool { return true }
+
+// hasNoBarcode returns true if the pass has no legacy barcode, which is the
+// only one displayed by older versions.
+func hasNoBarcode(p Pass) bool { return p.Barcode == nil }
+
+// isPoster returns true if the pass prefers the poster layout of event
+// tickets, which older versions can't display.
+func isPoster(p Pass) bool {
+	for _, scheme := range p.PreferredStyleSchemes {
+		if scheme == PKStyleSchemePosterEventTicket {
+			return true
+		}
+	}
+	return false
+}
+
+// Versions of the first releases supporting passes.
+var (
+	baseIOS     = Version{6, 0}
+	baseWatchOS = Version{2, 0}
+)
+
+// Versions supporting the keys.
+var (
+	ios7  = keyVersion{ios: Version{7, 0}, watchOS: baseWatchOS}
+	ios9  = keyVersion{ios: Version{9, 0}, watchOS: baseWatchOS}
+	ios11 = keyVersion{ios: Version{11, 0}, watchOS: Version{4, 0}}
+	ios12 = keyVersion{ios: Version{12, 0}, watchOS: Version{5, 0}}
+	ios18 = keyVersion{ios: Version{18, 0}, watchOS: Version{11, 0}}
+)
+
+// passKeyVersions lists the top-level keys of the pass requiring newer versions.
+// Older versions ignore the keys, so the pass is still usable without most of
+// them, but not without the barcode, NFC or the poster layout.
+var passKeyVersions = map[string]keyVersion{
+	"appLaunchURL":              ios7,
+	"beacons":                   ios7,
+	"maxDistance":               ios7,
+	"userInfo":                  ios7,
+	"expirationDate":            ios7,
+	"voided":                    ios7,
+	"groupingIdentifier":        ios7,
+	"barcodes":                  ios9.require(hasNoBarcode),
+	"nfc":                       ios9.require(always),
+	"sharingProhibited":         ios11,
+	"semantics":                 ios12,
+	"relevantDates":             ios18,
+	"preferredStyleSchemes":     ios18.require(isPoster),
+	"eventLogoText":             ios18,
+	"footerBackgroundColor":     ios18,
+	"useAutomaticColors":        ios18,
+	"suppressHeaderDarkening":   ios18,
+	"auxiliaryStoreIdentifiers": ios18,
+	"accessibilityURL":          ios18,
+	"addOnURL":                  ios18,
+	"bagPolicyURL":              ios18,
+	"contactVenueEmail":         ios18,
+	"contactVenuePhoneNumber":   ios18,
+	"contactVenueWebsite":       ios18,
+	"directionsInformationURL":  ios18,
+	"merchandiseURL":            ios18,
+	"orderFoodURL":              ios18,
+	"parkingInformationURL":     ios18,
+	"purchaseParkingURL":        ios18,
+	"sellURL":                   ios18,
+	"transferURL":               ios18,
+	"transitInformationURL":     ios18,
+}
+
+// fieldsKeyVersions lists the keys of the pass structure requiring newer versions.
+var fieldsKeyVersions = map[string]keyVersion{
+	"additionalInfoFields": ios18,
+}
+
+// fieldKeyVersions lists the keys of the field requiring newer versions.
+var fieldKeyVersions = map[string]keyVersion{
+	"attributedValue":   ios7,
+	"dataDetectorTypes": ios7,
+	"semantics":         ios12,
+	"row":               ios18,
+}
+
+// styleKeys lists the keys of the pass styles.
+var styleKeys = []string{"boardingPass", "coupon", "eventTicket", "generic", "storeCard"}
+
+// Compatibility returns the versions of iOS and watchOS required by the pass
+// and the keys requiring the versions newer than the first ones supporting
+// passes. The unrecognized keys from Extensions are analyzed too.
+func (p Pass) Compatibility() (*Compatibility, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var dict map[string]json.RawMessage
+	if err := json.Unmarshal(data, &dict); err != nil {
+		return nil, err
+	}
+	c := &Compatibility{IOS: baseIOS, WatchOS: baseWatchOS, FullIOS: baseIOS, FullWatchOS: baseWatchOS}
+	add := func(key string, version keyVersion) {
+		degrades := version.required == nil || !version.required(p)
+		c.Keys = append(c.Keys, KeyRequirement{key, version.ios, version.watchOS, degrades})
+		if c.FullIOS.Less(version.ios) {
+			c.FullIOS = version.ios
+		}
+		if c.FullWatchOS.Less(version.watchOS) {
+			c.FullWatchOS = version.watchOS
+		}
+		if degrades {
+			return
+		}
+		if c.IOS.Less(version.ios) {
+			c.IOS = version.ios
+		}
+		if c.WatchOS.Less(version.watchOS) {
+			c.WatchOS = version.watchOS
+		}
+	}
+	for _, key := range sortedKeys(dict) {
+		if version, ok := passKeyVersions[key]; ok {
+			add(key, version)
+		}
+		if !contains(styleKeys, key) {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(dict[key], &fields); err != nil {
+			continue
+		}
+		for _, fieldsKey := range sortedKeys(fields) {
+			if version, ok := fieldsKeyVersions[fieldsKey]; ok {
+				add(key+"."+fieldsKey, version)
+			}
+			var list []map[string]json.RawMessage
+			if err := json.Unmarshal(fields[fieldsKey], &list); err != nil {
+				continue
+			}
+			for i, field := range list {
+				for _, fieldKey := range sortedKeys(field) {
+					if version, ok := fieldKeyVersions[fieldKey]; ok {
+						add(fmt.Sprintf("%s.%s[%d].%s", key, fieldsKey, i, fieldKey), version)
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(c.Keys, func(i, j int) bool {
+		return c.Keys[j].IOS.Less(c.Keys[i].IOS)
+	})
+	return c, nil
+}
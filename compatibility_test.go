@@ -0,0 +1,94 @@
+package passbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPassCompatibility(t *testing.T) {
+	pass := Pass{
+		Beacons:  []Beacon{{ProximityUUID: "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0"}},
+		Barcode:  &Barcode{Format: PKBarcodeFormatQR, Message: "123"},
+		Barcodes: []Barcode{{Format: PKBarcodeFormatCode128, Message: "123"}},
+		EventTicket: &Fields{
+			Primary: FieldsData{{Key: "event", Value: "Concert",
+				Extensions: Extensions{"semantics": json.RawMessage(`{"eventName":"Concert"}`)}}},
+		},
+		SharingProhibited: true,
+	}
+	c, err := pass.Compatibility()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.IOS != baseIOS || c.WatchOS != baseWatchOS {
+		t.Errorf("minimum versions: iOS %v, watchOS %v", c.IOS, c.WatchOS)
+	}
+	if c.FullIOS != (Version{12, 0}) || c.FullWatchOS != (Version{5, 0}) {
+		t.Errorf("full versions: iOS %v, watchOS %v", c.FullIOS, c.FullWatchOS)
+	}
+	var keys []string
+	for _, key := range c.Keys {
+		keys = append(keys, key.Key)
+	}
+	want := []string{"eventTicket.primaryFields[0].semantics", "sharingProhibited", "barcodes", "beacons"}
+	if len(keys) != len(want) {
+		t.Fatalf("keys: %v", keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("keys: %v", keys)
+		}
+	}
+	// without the fallback barcode the pass is not usable on older versions
+	pass.Barcode = nil
+	if c, err = pass.Compatibility(); err != nil {
+		t.Fatal(err)
+	}
+	if c.IOS != (Version{9, 0}) {
+		t.Errorf("minimum version without fallback: iOS %v", c.IOS)
+	}
+}
+
+func TestPassCompatibilityRequired(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		edit    func(p *Pass)
+		ios     Version
+		watchOS Version
+	}{
+		{"legacy barcode", func(p *Pass) {
+			p.Barcode = &Barcode{Format: PKBarcodeFormatQR, Message: "123"}
+		}, baseIOS, baseWatchOS},
+		{"barcodes only", func(p *Pass) {
+			p.Barcodes = []Barcode{{Format: PKBarcodeFormatQR, Message: "123"}}
+		}, Version{9, 0}, baseWatchOS},
+		{"nfc", func(p *Pass) {
+			p.NFC = &NFC{Message: "VAS"}
+		}, Version{9, 0}, baseWatchOS},
+		{"event ticket scheme", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemeEventTicket}
+		}, baseIOS, baseWatchOS},
+		{"poster", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemePosterEventTicket, PKStyleSchemeEventTicket}
+			p.Semantics = &SemanticTags{EventName: "Concert", VenueName: "Arena"}
+		}, Version{18, 0}, Version{11, 0}},
+		{"ignored keys", func(p *Pass) {
+			p.SharingProhibited = true
+			p.EventLogoText = "Concert"
+			p.SuppressHeaderDarkening = true
+			p.BagPolicyURL = "https://example.com/bags"
+			p.AuxiliaryStoreIdentifiers = []int{284882215}
+			p.Semantics = &SemanticTags{EventName: "Concert"}
+		}, baseIOS, baseWatchOS},
+	} {
+		pass := testPass("eventTicket")
+		test.edit(&pass)
+		c, err := pass.Compatibility()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.IOS != test.ios || c.WatchOS != test.watchOS {
+			t.Errorf("%s: iOS %v, watchOS %v, want %v, %v", test.name, c.IOS, c.WatchOS, test.ios, test.watchOS)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package passbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Extensions keeps the keys of the dictionary that are not known by this
+// package, such as keys added in newer versions of iOS. They are written back
+// as is, so the pass can be read, changed and written without losing them.
+type Extensions map[string]json.RawMessage
+
+// knownKeys caches the JSON keys of the struct types by type.
+var knownKeys sync.Map
+
+// jsonKeys returns the JSON keys of the fields of the struct type.
+func jsonKeys(t reflect.Type) []string {
+	if keys, ok := knownKeys.Load(t); ok {
+		return keys.([]string)
+	}
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		keys = append(keys, name)
+	}
+	knownKeys.Store(t, keys)
+	return keys
+}
+
+// isKnownKey returns true if the key is decoded into one of the fields of v.
+// Like encoding/json, the keys are compared without case.
+func isKnownKey(v interface{}, key string) bool {
+	for _, name := range jsonKeys(reflect.TypeOf(v).Elem()) {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalExtensions decodes the JSON object into v, which is a pointer to
+// a struct, and returns the keys that are not decoded into it.
+func unmarshalExtensions(data []byte, v interface{}) (Extensions, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	var extensions Extensions
+	for key, value := range all {
+		if isKnownKey(v, key) {
+			continue
+		}
+		if extensions == nil {
+			extensions = make(Extensions)
+		}
+		extensions[key] = value
+	}
+	return extensions, nil
+}
+
+// marshalExtensions encodes v, which is a pointer to a struct, as JSON object
+// and appends the extension keys to it in sorted order. The extensions that
+// duplicate the known keys are ignored.
+func marshalExtensions(v interface{}, extensions Extensions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendExtensions(data, v, extensions)
+}
+
+// appendExtensions appends the extension keys in sorted order to the JSON
+// object data, which is the encoded v. The extensions that duplicate the known
+// keys of v are ignored.
+func appendExtensions(data []byte, v interface{}, extensions Extensions) ([]byte, error) {
+	if len(extensions) == 0 {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1]) // without closing brace
+	empty := len(data) == 2
+	for _, key := range sortedKeys(extensions) {
+		if isKnownKey(v, key) {
+			continue
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			buf.WriteByte(',')
+		}
+		empty = false
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// passJSON is the Pass without JSON methods.
+type passJSON Pass
+
+func (p Pass) MarshalJSON() ([]byte, error) {
+	return marshalExtensions((*passJSON)(&p), p.Extensions)
+}
+
+func (p *Pass) UnmarshalJSON(data []byte) error {
+	extensions, err := unmarshalExtensions(data, (*passJSON)(p))
+	p.Extensions = extensions
+	return err
+}
+
+// fieldsJSON is the Fields without JSON methods.
+type fieldsJSON Fields
+
+func (f Fields) MarshalJSON() ([]byte, error) {
+	return marshalExtensions((*fieldsJSON)(&f), f.Extensions)
+}
+
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	extensions, err := unmarshalExtensions(data, (*fieldsJSON)(f))
+	f.Extensions = extensions
+	return err
+}
+
+// fieldJSON is the Field without JSON methods.
+type fieldJSON Field
+
+func (f Field) MarshalJSON() ([]byte, error) {
+	// the empty list of data detectors disables them and is written, unlike
+	// the nil list
+	v := struct {
+		*fieldJSON
+		DataDetectorTypes *[]DataDetector `json:"dataDetectorTypes,omitempty"`
+	}{fieldJSON: (*fieldJSON)(&f)}
+	if f.DataDetectorTypes != nil {
+		v.DataDetectorTypes = &f.DataDetectorTypes
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendExtensions(data, (*fieldJSON)(&f), f.Extensions)
+}
+
+func (f *Field) UnmarshalJSON(data []byte) error {
+	extensions, err := unmarshalExtensions(data, (*fieldJSON)(f))
+	f.Extensions = extensions
+	return err
+}
+
+// semanticTagsJSON is the SemanticTags without JSON methods.
+type semanticTagsJSON SemanticTags
+
+func (s SemanticTags) MarshalJSON() ([]byte, error) {
+	return marshalExtensions((*semanticTagsJSON)(&s), s.Extensions)
+}
+
+func (s *SemanticTags) UnmarshalJSON(data []byte) error {
+	extensions, err := unmarshalExtensions(data, (*semanticTagsJSON)(s))
+	s.Extensions = extensions
+	return err
+}
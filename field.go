@@ -3,13 +3,13 @@ package passbook
 // Standard Field Dictionary Keys: Information about a field.
 // These keys are used for all dictionaries that define a field.
 type Field struct {
-	Key             string        `json:"key"`                       // The key must be unique within the scope of the entire pass.
-	Label           string        `json:"label,omitempty"`           // Label text for the field.
-	Value           interface{}   `json:"value"`                     // Value of the field.
-	AttributedValue string        `json:"attributedValue,omitempty"` // Attributed value of the field.
-	TextAlignment   TextAlignment `json:"textAlignment,omitempty"`   // Alignment for the field’s contents.
-	ChangeMessage   string        `json:"changeMessage,omitempty"`   // Format string for the alert text that is displayed when the pass is updated. The format string must contain the escape %@, which is replaced with the field’s new value. For example, “Gate changed to %@.”
-	// DataDetectorTypes []DataDetector `json:"dataDetectorTypes,omitempty` // Data dectors that are applied to the field’s value.
+	Key               string         `json:"key"`                         // The key must be unique within the scope of the entire pass.
+	Label             string         `json:"label,omitempty"`             // Label text for the field.
+	Value             interface{}    `json:"value"`                       // Value of the field.
+	AttributedValue   string         `json:"attributedValue,omitempty"`   // Attributed value of the field.
+	TextAlignment     TextAlignment  `json:"textAlignment,omitempty"`     // Alignment for the field’s contents.
+	ChangeMessage     string         `json:"changeMessage,omitempty"`     // Format string for the alert text that is displayed when the pass is updated. The format string must contain the escape %@, which is replaced with the field’s new value. For example, “Gate changed to %@.”
+	DataDetectorTypes []DataDetector `json:"dataDetectorTypes,omitempty"` // Data detectors that are applied to the field’s value. An empty list disables the data detectors; nil list is not written.
 	// Date Style Keys: Information about how a date should be displayed in a field.
 	// If any of these keys is present, the value of the field is treated as a date. Either specify both a date style and a time style, or neither.
 	DateStyle       DateTimeStyle `json:"dateStyle,omitempty"`       // Style of date to display.
@@ -20,4 +20,6 @@ type Field struct {
 	// These keys are optional if the field’s value is a number; otherwise they are not allowed. Only one of these keys is allowed per field.
 	CurrencyCode string      `json:"currencyCode,omitempty"` // ISO 4217 currency code for the field’s value.
 	NumberStyle  NumberStyle `json:"numberStyle,omitempty"`  // Style of number to display.
+	// Unrecognized keys, such as keys of newer versions, preserved as is.
+	Extensions Extensions `json:"-"`
 }
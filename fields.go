@@ -3,12 +3,14 @@ package passbook
 // Fields Pass Structure Dictionary: Keys that define the structure of the pass.
 // These keys are used for all pass styles and partition the fields into the various parts of the pass.
 type Fields struct {
-	TransitType TransitType `json:"transitType,omitempty"`     // Type of transit. Required for boarding passes; otherwise not allowed.
-	Primary     FieldsData  `json:"primaryFields,omitempty"`   // Fields to be displayed prominently on the front of the pass.
-	Secondary   FieldsData  `json:"secondaryFields,omitempty"` // Fields to be displayed on the front of the pass.
-	Auxiliary   FieldsData  `json:"auxiliaryFields,omitempty"` // Additional fields to be displayed on the front of the pass.
-	Back        FieldsData  `json:"backFields,omitempty"`      // Fields to be on the back of the pass.
-	Header      FieldsData  `json:"headerFields,omitempty"`    // Fields to be displayed in the header on the front of the pass.
+	TransitType    TransitType `json:"transitType,omitempty"`          // Type of transit. Required for boarding passes; otherwise not allowed.
+	Primary        FieldsData  `json:"primaryFields,omitempty"`        // Fields to be displayed prominently on the front of the pass.
+	Secondary      FieldsData  `json:"secondaryFields,omitempty"`      // Fields to be displayed on the front of the pass.
+	Auxiliary      FieldsData  `json:"auxiliaryFields,omitempty"`      // Additional fields to be displayed on the front of the pass.
+	Back           FieldsData  `json:"backFields,omitempty"`           // Fields to be on the back of the pass.
+	Header         FieldsData  `json:"headerFields,omitempty"`         // Fields to be displayed in the header on the front of the pass.
+	AdditionalInfo FieldsData  `json:"additionalInfoFields,omitempty"` // Fields to be displayed below the poster event ticket. Allowed only for event tickets. Available in iOS 18.0.
+	Extensions     Extensions  `json:"-"`                              // Unrecognized keys, such as keys of newer versions, preserved as is.
 }
 
 // FieldsData describe array of field dictionaries
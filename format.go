@@ -0,0 +1,111 @@
+package passbook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+
+	"github.com/mdigger/passbook/internal/format"
+)
+
+// Layouts of the dates and the times by the styles, as displayed in English.
+var (
+	dateLayouts = map[DateTimeStyle]string{
+		PKDateStyleShort:  "1/2/06",
+		PKDateStyleMedium: "Jan 2, 2006",
+		PKDateStyleLong:   "January 2, 2006",
+		PKDateStyleFull:   "Monday, January 2, 2006",
+	}
+	timeLayouts = map[DateTimeStyle]string{
+		PKDateStyleShort:  "3:04 PM",
+		PKDateStyleMedium: "3:04:05 PM",
+		PKDateStyleLong:   "3:04:05 PM MST",
+		PKDateStyleFull:   "3:04:05 PM MST",
+	}
+)
+
+// Display returns the label and the value of the field as they are shown on
+// the pass. The label and the string value are replaced by the localized
+// strings, if any. The dates and the numbers are formatted by the styles of
+// the field in English; relative dates are displayed as absolute.
+func (f Field) Display(localized map[string]string) (label, value string) {
+	label = format.Localize(localized, f.Label)
+	switch v := f.Value.(type) {
+	case string:
+		value = format.Localize(localized, v)
+		if f.DateStyle != "" || f.TimeStyle != "" {
+			if date, ok := f.displayDate(v); ok {
+				value = date
+			}
+		}
+	case float64:
+		value = f.displayNumber(v)
+	case int:
+		value = f.displayNumber(float64(v))
+	case nil:
+	default:
+		value = fmt.Sprint(v)
+	}
+	return label, value
+}
+
+// displayDate formats the value of the field as a date by the date and time
+// styles. The date is displayed in its own time zone.
+func (f Field) displayDate(value string) (string, bool) {
+	var date W3Time
+	if err := date.UnmarshalJSON([]byte(strconv.Quote(value))); err != nil {
+		return "", false
+	}
+	t := time.Time(date)
+	var parts []string
+	if layout, ok := dateLayouts[f.DateStyle]; ok {
+		parts = append(parts, t.Format(layout))
+	}
+	if layout, ok := timeLayouts[f.TimeStyle]; ok {
+		parts = append(parts, t.Format(layout))
+	}
+	return strings.Join(parts, " at "), true
+}
+
+// displayNumber formats the number by the currency code or the number style.
+func (f Field) displayNumber(value float64) string {
+	p := message.NewPrinter(language.English)
+	if f.CurrencyCode != "" {
+		if unit, err := currency.ParseISO(f.CurrencyCode); err == nil {
+			scale, _ := currency.Standard.Rounding(unit)
+			symbol := p.Sprint(currency.NarrowSymbol(unit))
+			amount := p.Sprint(number.Decimal(value, number.Scale(scale)))
+			if symbol == unit.String() {
+				return symbol + " " + amount
+			}
+			return symbol + amount
+		}
+		return p.Sprint(number.Decimal(value)) + " " + f.CurrencyCode
+	}
+	switch f.NumberStyle {
+	case PKNumberStylePercent:
+		return p.Sprint(number.Percent(value))
+	case PKNumberStyleScientific:
+		s := strconv.FormatFloat(value, 'E', -1, 64)
+		i := strings.IndexByte(s, 'E')
+		exp, _ := strconv.Atoi(s[i+1:])
+		return s[:i] + "E" + strconv.Itoa(exp)
+	}
+	return p.Sprint(number.Decimal(value))
+}
+
+// DisplayedBarcode returns the barcode displayed by the current devices: the
+// first one from the barcodes array or, if it is empty, the legacy barcode.
+// It returns nil if the pass has no barcode.
+func (p Pass) DisplayedBarcode() *Barcode {
+	if len(p.Barcodes) > 0 {
+		return &p.Barcodes[0]
+	}
+	return p.Barcode
+}
@@ -0,0 +1,27 @@
+package passbook
+
+import "testing"
+
+func TestFieldDisplay(t *testing.T) {
+	localized := map[string]string{"gate": "Выход"}
+	for _, test := range []struct {
+		field        Field
+		label, value string
+	}{
+		{Field{Label: "gate", Value: "A1"}, "Выход", "A1"},
+		{Field{Value: "2026-10-19T18:30-04:00", DateStyle: PKDateStyleMedium, TimeStyle: PKDateStyleShort}, "", "Oct 19, 2026 at 6:30 PM"},
+		{Field{Value: "2026-10-19T18:30Z", DateStyle: PKDateStyleFull}, "", "Monday, October 19, 2026"},
+		{Field{Value: "soon", DateStyle: PKDateStyleShort}, "", "soon"},
+		{Field{Value: 1234.5, CurrencyCode: "USD"}, "", "$1,234.50"},
+		{Field{Value: 1234.5, CurrencyCode: "JPY"}, "", "¥1,234"},
+		{Field{Value: 20.0, CurrencyCode: "CHF"}, "", "CHF 20.00"},
+		{Field{Value: 0.25, NumberStyle: PKNumberStylePercent}, "", "25%"},
+		{Field{Value: 12345.0, NumberStyle: PKNumberStyleScientific}, "", "1.2345E4"},
+		{Field{Value: 1234567.0}, "", "1,234,567"},
+	} {
+		label, value := test.field.Display(localized)
+		if label != test.label || value != test.value {
+			t.Errorf("%v: %q %q, want %q %q", test.field.Value, label, value, test.label, test.value)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package format contains the formatting helpers shared by the passbook
+// package and its preview.
+package format
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Localize returns the localized string for the key or the key itself.
+func Localize(localized map[string]string, key string) string {
+	if s, ok := localized[key]; ok {
+		return s
+	}
+	return key
+}
+
+// CSSColor returns the color in CSS format with the opacity, as used in SVG.
+func CSSColor(c color.Color) string {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return "none"
+	}
+	// colors are returned with premultiplied alpha
+	r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+	if a == 0xffff {
+		return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", r>>8, g>>8, b>>8, float64(a)/0xffff)
+}
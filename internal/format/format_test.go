@@ -0,0 +1,32 @@
+package format
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	localized := map[string]string{"title": "Билет"}
+	if s := Localize(localized, "title"); s != "Билет" {
+		t.Errorf("localized: %q", s)
+	}
+	if s := Localize(localized, "Gate"); s != "Gate" {
+		t.Errorf("not localized: %q", s)
+	}
+	if s := Localize(nil, "Gate"); s != "Gate" {
+		t.Errorf("without strings: %q", s)
+	}
+}
+
+func TestCSSColor(t *testing.T) {
+	for c, want := range map[color.Color]string{
+		color.Black:                         "#000000",
+		color.RGBA{0x12, 0xab, 0xff, 0xff}:  "#12abff",
+		color.Transparent:                   "none",
+		color.NRGBA{0xff, 0x00, 0x00, 0x80}: "rgba(255,0,0,0.502)",
+	} {
+		if s := CSSColor(c); s != want {
+			t.Errorf("%v: %q, want %q", c, s, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package wallet contains the rules of Wallet for the layout of the pass,
+// shared by the preview images and the terminal output.
+package wallet
+
+import (
+	"strings"
+
+	"github.com/mdigger/passbook"
+)
+
+// FrontRows returns the rows of the secondary and auxiliary fields on the
+// front of the pass of the style in the order displayed by Wallet. The rows
+// are limited to the number of the fields displayed by Wallet and empty rows
+// are omitted.
+func FrontRows(style string, fields *passbook.Fields) []passbook.FieldsData {
+	var rows []passbook.FieldsData
+	max := 4
+	switch style {
+	case "coupon", "storeCard":
+		// the secondary and auxiliary fields share one row
+		rows = append(rows, append(append(passbook.FieldsData{}, fields.Secondary...), fields.Auxiliary...))
+	case "boardingPass":
+		rows = append(rows, fields.Auxiliary, fields.Secondary)
+		max = 5
+	default:
+		rows = append(rows, fields.Secondary, fields.Auxiliary)
+	}
+	result := rows[:0]
+	for _, row := range rows {
+		if len(row) > max {
+			row = row[:max]
+		}
+		if len(row) > 0 {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// ellipsis is appended to the truncated text.
+const ellipsis = "…"
+
+// Fit returns the text truncated to the width measured by the function.
+func Fit(text string, width float64, measure func(string) float64) string {
+	if measure(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		truncated := strings.TrimRight(string(runes[:n]), " ") + ellipsis
+		if measure(truncated) <= width {
+			return truncated
+		}
+	}
+	return ellipsis
+}
+
+// Wrap splits the text to the lines of the given width by the words, as the
+// back fields are wrapped. The width of the text is returned by measure, e.g.
+// the number of characters for the terminal. Line breaks of the text are
+// preserved and too long words are truncated.
+func Wrap(text string, width float64, measure func(string) float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+				continue
+			}
+			if measure(line+" "+word) <= width {
+				line += " " + word
+				continue
+			}
+			lines = append(lines, Fit(line, width, measure))
+			line = word
+		}
+		lines = append(lines, Fit(line, width, measure))
+	}
+	return lines
+}
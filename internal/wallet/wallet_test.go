@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mdigger/passbook"
+)
+
+func TestFrontRows(t *testing.T) {
+	fields := func(n int) passbook.FieldsData {
+		var result passbook.FieldsData
+		for i := 0; i < n; i++ {
+			result = append(result, passbook.Field{Key: strconv.Itoa(i)})
+		}
+		return result
+	}
+	for _, test := range []struct {
+		style                string
+		secondary, auxiliary int
+		want                 []int
+	}{
+		{"generic", 2, 3, []int{2, 3}},
+		{"generic", 6, 0, []int{4}},
+		{"eventTicket", 0, 5, []int{4}},
+		{"boardingPass", 6, 2, []int{2, 5}},
+		{"coupon", 3, 3, []int{4}},
+		{"storeCard", 0, 0, nil},
+	} {
+		var got []int
+		for _, row := range FrontRows(test.style, &passbook.Fields{
+			Secondary: fields(test.secondary), Auxiliary: fields(test.auxiliary)}) {
+			got = append(got, len(row))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %d+%d: rows %v, want %v", test.style, test.secondary, test.auxiliary, got, test.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	width := func(s string) float64 { return float64(utf8.RuneCountInString(s)) }
+	got := Wrap("Один два три\n\nчетыре длинноеслово", 9, width)
+	want := []string{"Один два", "три", "", "четыре", "длинноес…"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%q, want %q", got, want)
+	}
+}
+
+func TestFit(t *testing.T) {
+	width := func(s string) float64 { return float64(utf8.RuneCountInString(s)) }
+	for text, want := range map[string]string{
+		"Gate":         "Gate",
+		"Departure":    "Departure",
+		"Departure 23": "Departur…",
+		"A       long": "A…",
+	} {
+		if got := Fit(text, 9, width); got != want {
+			t.Errorf("%q: %q, want %q", text, got, want)
+		}
+	}
+	if got := Fit("Gate", 0, width); got != ellipsis {
+		t.Errorf("zero width: %q", got)
+	}
+}
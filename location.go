@@ -1,5 +1,10 @@
 package passbook
 
+import (
+	"errors"
+	"math"
+)
+
 // Location Dictionary: Information about a location.
 type Location struct {
 	Latitude     float64 `json:"latitude"`               // Latitude, in degrees, of the location.
@@ -7,3 +12,36 @@ type Location struct {
 	Altitude     float64 `json:"altitude,omitempty"`     // Altitude, in meters, of the location.
 	RelevantText string  `json:"relevantText,omitempty"` // Text displayed on the lock screen when the pass is currently relevant.
 }
+
+// validate checks the ranges of the coordinates.
+func (l Location) validate() error {
+	if !(l.Latitude >= -90 && l.Latitude <= 90) {
+		return errors.New("Latitude must be between -90 and 90 degrees")
+	}
+	if !(l.Longitude >= -180 && l.Longitude <= 180) {
+		return errors.New("Longitude must be between -180 and 180 degrees")
+	}
+	if math.IsNaN(l.Altitude) || math.IsInf(l.Altitude, 0) {
+		return errors.New("Bad altitude")
+	}
+	return nil
+}
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371000
+
+// distance returns the distance in meters between the locations on the
+// surface of the Earth. The altitude is ignored.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Distance returns the distance in meters from the location to the point with
+// the given coordinates.
+func (l Location) Distance(latitude, longitude float64) float64 {
+	return distance(l.Latitude, l.Longitude, latitude, longitude)
+}
@@ -0,0 +1,293 @@
+package passbook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Migrate upgrades the legacy keys of the pass to their modern equivalents and
+// returns the descriptions of the changes. The barcode is copied to the
+// barcodes array and the relevant date to the relevant dates, and the legacy
+// keys are kept for older devices. The semantic tags are derived from the
+// fields with well-known keys, such as "gate" or "seat", unless they are
+// already defined.
+func (p *Pass) Migrate() []string {
+	var changes []string
+	if p.FormatVersion != 1 {
+		changes = append(changes, fmt.Sprintf("formatVersion changed from %d to 1", p.FormatVersion))
+		p.FormatVersion = 1
+	}
+	if p.Barcode != nil && len(p.Barcodes) == 0 {
+		p.Barcodes = []Barcode{*p.Barcode}
+		changes = append(changes, "barcode copied to barcodes")
+	}
+	if p.RelevantDate != nil && len(p.RelevantDates) == 0 {
+		date := *p.RelevantDate
+		p.RelevantDates = []RelevantDate{{Date: &date}}
+		changes = append(changes, "relevantDate copied to relevantDates")
+	}
+	style, fields := p.Style()
+	if fields == nil {
+		return changes
+	}
+	tags := p.Semantics
+	if tags == nil {
+		tags = new(SemanticTags)
+	}
+	for _, section := range []FieldsData{fields.Header, fields.Primary,
+		fields.Secondary, fields.Auxiliary, fields.AdditionalInfo, fields.Back} {
+		for _, field := range section {
+			migrate, ok := semanticFields[semanticKey(field.Key)]
+			if !ok {
+				continue
+			}
+			if tag := migrate(tags, style, fields.TransitType, field); tag != "" {
+				changes = append(changes,
+					fmt.Sprintf("semantics.%s derived from field %q", tag, field.Key))
+			}
+		}
+	}
+	if p.Semantics == nil && !isEmptySemantics(tags) {
+		p.Semantics = tags
+	}
+	return changes
+}
+
+// semanticKey returns the key of the field in lower case without separators,
+// such as "flightnumber" for "flight_number".
+func semanticKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// isEmptySemantics returns true if no semantic tags are defined.
+func isEmptySemantics(tags *SemanticTags) bool {
+	data, err := tags.MarshalJSON()
+	return err == nil && string(data) == "{}"
+}
+
+// semanticMigration sets the semantic tag from the value of the field, if the
+// tag is not defined yet and the field is suitable for the pass style. It
+// returns the name of the set tag or the empty string.
+type semanticMigration func(tags *SemanticTags, style string, transit TransitType, field Field) string
+
+// semanticFields lists the migrations of the semantic tags by the keys of the
+// fields, as returned by semanticKey.
+var semanticFields = map[string]semanticMigration{
+	"event":              eventString("eventName", func(t *SemanticTags) *string { return &t.EventName }),
+	"eventname":          eventString("eventName", func(t *SemanticTags) *string { return &t.EventName }),
+	"venue":              eventString("venueName", func(t *SemanticTags) *string { return &t.VenueName }),
+	"venuename":          eventString("venueName", func(t *SemanticTags) *string { return &t.VenueName }),
+	"room":               eventString("venueRoom", func(t *SemanticTags) *string { return &t.VenueRoom }),
+	"entrance":           eventString("venueEntrance", func(t *SemanticTags) *string { return &t.VenueEntrance }),
+	"date":               eventDate("eventStartDate", func(t *SemanticTags) **W3Time { return &t.EventStartDate }),
+	"eventdate":          eventDate("eventStartDate", func(t *SemanticTags) **W3Time { return &t.EventStartDate }),
+	"starts":             eventDate("eventStartDate", func(t *SemanticTags) **W3Time { return &t.EventStartDate }),
+	"ends":               eventDate("eventEndDate", func(t *SemanticTags) **W3Time { return &t.EventEndDate }),
+	"seat":               seat("seatNumber", func(s *SemanticSeat) *string { return &s.SeatNumber }),
+	"row":                seat("seatRow", func(s *SemanticSeat) *string { return &s.SeatRow }),
+	"section":            seat("seatSection", func(s *SemanticSeat) *string { return &s.SeatSection }),
+	"gate":               transitString("departureGate", func(t *SemanticTags) *string { return &t.DepartureGate }),
+	"terminal":           transitString("departureTerminal", func(t *SemanticTags) *string { return &t.DepartureTerminal }),
+	"confirmation":       transitString("confirmationNumber", func(t *SemanticTags) *string { return &t.ConfirmationNumber }),
+	"confirmationnumber": transitString("confirmationNumber", func(t *SemanticTags) *string { return &t.ConfirmationNumber }),
+	"pnr":                transitString("confirmationNumber", func(t *SemanticTags) *string { return &t.ConfirmationNumber }),
+	"group":              transitString("boardingGroup", func(t *SemanticTags) *string { return &t.BoardingGroup }),
+	"boardinggroup":      transitString("boardingGroup", func(t *SemanticTags) *string { return &t.BoardingGroup }),
+	"zone":               transitString("boardingGroup", func(t *SemanticTags) *string { return &t.BoardingGroup }),
+	"sequence":           transitString("boardingSequenceNumber", func(t *SemanticTags) *string { return &t.BoardingSequenceNumber }),
+	"train":              transitString("vehicleNumber", func(t *SemanticTags) *string { return &t.VehicleNumber }),
+	"vehicle":            transitString("vehicleNumber", func(t *SemanticTags) *string { return &t.VehicleNumber }),
+	"flight":             flightCode,
+	"flightnumber":       flightCode,
+	"flightcode":         flightCode,
+	"origin":             departure,
+	"from":               departure,
+	"depart":             departure,
+	"departure":          departure,
+	"destination":        destination,
+	"to":                 destination,
+	"arrive":             destination,
+	"arrival":            destination,
+	"passenger":          passengerName,
+	"passengername":      passengerName,
+	"balance":            amount("balance", func(t *SemanticTags) **SemanticAmount { return &t.Balance }),
+	"total":              amount("totalPrice", func(t *SemanticTags) **SemanticAmount { return &t.TotalPrice }),
+	"price":              amount("totalPrice", func(t *SemanticTags) **SemanticAmount { return &t.TotalPrice }),
+}
+
+// fieldString returns the value of the field as a string.
+func fieldString(field Field) string {
+	switch value := field.Value.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(value)
+	}
+	return ""
+}
+
+// setString sets the tag to the value of the field, if it is not set yet.
+func setString(tag *string, field Field) bool {
+	value := fieldString(field)
+	if *tag != "" || value == "" {
+		return false
+	}
+	*tag = value
+	return true
+}
+
+// eventString returns the migration of the string tag of event tickets.
+func eventString(name string, tag func(*SemanticTags) *string) semanticMigration {
+	return func(tags *SemanticTags, style string, _ TransitType, field Field) string {
+		if style != "eventTicket" || !setString(tag(tags), field) {
+			return ""
+		}
+		return name
+	}
+}
+
+// eventDate returns the migration of the date tag of event tickets.
+func eventDate(name string, tag func(*SemanticTags) **W3Time) semanticMigration {
+	return func(tags *SemanticTags, style string, _ TransitType, field Field) string {
+		value, ok := field.Value.(string)
+		if style != "eventTicket" || !ok || *tag(tags) != nil {
+			return ""
+		}
+		var date W3Time
+		if err := date.UnmarshalJSON([]byte(`"` + value + `"`)); err != nil {
+			return ""
+		}
+		*tag(tags) = &date
+		return name
+	}
+}
+
+// seat returns the migration of the tag of the first seat of event tickets
+// and boarding passes.
+func seat(name string, tag func(*SemanticSeat) *string) semanticMigration {
+	return func(tags *SemanticTags, style string, _ TransitType, field Field) string {
+		if style != "eventTicket" && style != "boardingPass" {
+			return ""
+		}
+		if len(tags.Seats) == 0 {
+			tags.Seats = make([]SemanticSeat, 1)
+		}
+		if !setString(tag(&tags.Seats[0]), field) {
+			if tags.Seats[0] == (SemanticSeat{}) {
+				tags.Seats = nil
+			}
+			return ""
+		}
+		return "seats." + name
+	}
+}
+
+// transitString returns the migration of the string tag of boarding passes.
+func transitString(name string, tag func(*SemanticTags) *string) semanticMigration {
+	return func(tags *SemanticTags, style string, _ TransitType, field Field) string {
+		if style != "boardingPass" || !setString(tag(tags), field) {
+			return ""
+		}
+		return name
+	}
+}
+
+// flightCode sets the flight code of air boarding passes.
+func flightCode(tags *SemanticTags, style string, transit TransitType, field Field) string {
+	if transit != PKTransitTypeAir {
+		return ""
+	}
+	return transitString("flightCode", func(t *SemanticTags) *string { return &t.FlightCode })(
+		tags, style, transit, field)
+}
+
+// departure sets the departure airport code, station name or location
+// description of boarding passes, depending on the type of transit.
+func departure(tags *SemanticTags, style string, transit TransitType, field Field) string {
+	return transitPlace(tags, style, transit, field, &tags.DepartureAirportCode,
+		&tags.DepartureStationName, &tags.DepartureLocationDescription, "departure")
+}
+
+// destination sets the destination airport code, station name or location
+// description of boarding passes, depending on the type of transit.
+func destination(tags *SemanticTags, style string, transit TransitType, field Field) string {
+	return transitPlace(tags, style, transit, field, &tags.DestinationAirportCode,
+		&tags.DestinationStationName, &tags.DestinationLocationDescription, "destination")
+}
+
+// transitPlace sets one of the tags of the place of boarding passes.
+func transitPlace(tags *SemanticTags, style string, transit TransitType, field Field,
+	airportCode, stationName, description *string, prefix string) string {
+	if style != "boardingPass" {
+		return ""
+	}
+	switch value := fieldString(field); {
+	case transit == PKTransitTypeAir && isAirportCode(value):
+		if setString(airportCode, field) {
+			return prefix + "AirportCode"
+		}
+	case transit == PKTransitTypeTrain:
+		if setString(stationName, field) {
+			return prefix + "StationName"
+		}
+	default:
+		if setString(description, field) {
+			return prefix + "LocationDescription"
+		}
+	}
+	return ""
+}
+
+// isAirportCode returns true if the value looks like IATA airport code.
+func isAirportCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// passengerName sets the name of the passenger of boarding passes. The last
+// word of the name is used as the family name.
+func passengerName(tags *SemanticTags, style string, _ TransitType, field Field) string {
+	value := fieldString(field)
+	if style != "boardingPass" || tags.PassengerName != nil || value == "" {
+		return ""
+	}
+	name := new(SemanticPersonName)
+	if i := strings.LastIndexByte(value, ' '); i > 0 {
+		name.GivenName, name.FamilyName = strings.TrimSpace(value[:i]), value[i+1:]
+	} else {
+		name.FamilyName = value
+	}
+	tags.PassengerName = name
+	return "passengerName"
+}
+
+// amount returns the migration of the amount of money with currency code.
+func amount(name string, tag func(*SemanticTags) **SemanticAmount) semanticMigration {
+	return func(tags *SemanticTags, _ string, _ TransitType, field Field) string {
+		value := fieldString(field)
+		if field.CurrencyCode == "" || value == "" || *tag(tags) != nil {
+			return ""
+		}
+		if _, ok := field.Value.(string); ok {
+			return "" // only numbers can be converted without ambiguity
+		}
+		*tag(tags) = &SemanticAmount{Amount: value, CurrencyCode: field.CurrencyCode}
+		return name
+	}
+}
@@ -0,0 +1,40 @@
+package passbook
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestPassMigrate(t *testing.T) {
+	data, err := os.ReadFile("testdata/passes/boardingPass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pass Pass
+	if err := json.Unmarshal(data, &pass); err != nil {
+		t.Fatal(err)
+	}
+	changes := pass.Migrate()
+	if len(changes) == 0 {
+		t.Fatal("no changes")
+	}
+	if len(pass.Barcodes) != 1 || pass.Barcodes[0] != *pass.Barcode {
+		t.Errorf("barcodes: %v", pass.Barcodes)
+	}
+	if len(pass.RelevantDates) != 1 || pass.RelevantDates[0].Date == pass.RelevantDate {
+		t.Errorf("relevant dates: %v", pass.RelevantDates)
+	}
+	tags := pass.Semantics
+	if tags == nil || tags.DepartureAirportCode != "SFO" || tags.DestinationAirportCode != "JFK" ||
+		tags.DepartureGate != "23" || tags.PassengerName == nil || tags.PassengerName.FamilyName != "Appleseed" {
+		t.Errorf("semantics: %+v", tags)
+	}
+	if _, err := pass.Marshal(); err != nil {
+		t.Error(err)
+	}
+	// the second migration changes nothing
+	if changes := pass.Migrate(); len(changes) != 0 {
+		t.Errorf("repeated changes: %v", changes)
+	}
+}
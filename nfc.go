@@ -0,0 +1,39 @@
+package passbook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+// NFC Dictionary: Information about the Near Field Communication (NFC) payload
+// passed to an Apple Pay terminal. Available in iOS 9.0.
+type NFC struct {
+	Message                string `json:"message"`                          // Payload to be transmitted to the Apple Pay terminal. Must be 64 bytes or less.
+	EncryptionPublicKey    string `json:"encryptionPublicKey"`              // Public encryption key used by the Value Added Services protocol: Base64 encoded X.509 SubjectPublicKeyInfo structure containing a ECDH public key for group P256.
+	RequiresAuthentication bool   `json:"requiresAuthentication,omitempty"` // Indicates whether the NFC pass requires authentication.
+}
+
+// validate checks the message and the encryption public key.
+func (n NFC) validate() error {
+	if n.Message == "" {
+		return errors.New("NFC message must be set")
+	}
+	if len(n.Message) > 64 {
+		return errors.New("NFC message must be 64 bytes or less")
+	}
+	der, err := base64.StdEncoding.DecodeString(n.EncryptionPublicKey)
+	if err != nil {
+		return errors.New("NFC encryption public key must be Base64 encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return errors.New("Bad NFC encryption public key: " + err.Error())
+	}
+	if pub, ok := pub.(*ecdsa.PublicKey); !ok || pub.Curve != elliptic.P256() {
+		return errors.New("NFC encryption public key must be ECDH P-256 key")
+	}
+	return nil
+}
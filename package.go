@@ -0,0 +1,166 @@
+package passbook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Package describes the whole content of the pass: the description, images,
+// localizations and personalization data. It allows to create a signed
+// Passbook file in one call.
+type Package struct {
+	Pass            Pass                    // Description of the pass
+	Images          map[string][]byte       // Images by file name, e.g. "icon.png" or "logo@2x.png"
+	Localizations   map[string]Localization // Localized resources by language code, e.g. "en" or "zh-Hans"
+	Personalization *Personalization        // Reward program sign-up information, if any
+	ModTime         time.Time               // If set, the output is reproducible, see Writer.SetReproducible
+}
+
+// Localization contains the resources of the pass localized for one language.
+type Localization struct {
+	Strings map[string]string // Localized strings that are written to pass.strings
+	Images  map[string][]byte // Localized images by file name
+}
+
+// Validate checks the description of the pass and the set of files in the package.
+func (p *Package) Validate() error {
+	if err := p.Pass.Validate(); err != nil {
+		return err
+	}
+	if _, ok := p.Images["icon.png"]; !ok {
+		return errors.New("icon.png missed")
+	}
+	for name := range p.Images {
+		if err := checkImageName(name); err != nil {
+			return err
+		}
+	}
+	if p.Personalization != nil {
+		if p.Pass.StoreCard == nil {
+			return errors.New("Personalization is available only for store cards")
+		}
+		if _, ok := p.Images["personalizationLogo.png"]; !ok {
+			return errors.New("personalizationLogo.png missed")
+		}
+	}
+	for lang, localization := range p.Localizations {
+		if lang == "" || strings.ContainsAny(lang, "/\\.") {
+			return fmt.Errorf("Bad localization language %q", lang)
+		}
+		for name := range localization.Images {
+			if err := checkImageName(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkImageName returns an error if the name is not suitable as a name of image in the package.
+func checkImageName(name string) error {
+	if path.Ext(name) != ".png" || path.Base(name) != name {
+		return fmt.Errorf("Bad image name %q", name)
+	}
+	return nil
+}
+
+// WriteSigned validates the package and writes it as a signed Passbook file
+// to w. If the signer is CertificateSigner, the empty identifiers of the pass
+// are filled from its certificate. The method is not named WriteTo, as it takes
+// the signer and would clash with the signature of io.WriterTo. If a file can't
+// be written, the archive is finished without the manifest and the signature.
+func (p *Package) WriteSigned(w io.Writer, signer Signer) error {
+	if signer, ok := signer.(CertificateSigner); ok {
+		filled := *p
+		if err := filled.Pass.fillIdentifiers(signer.SigningCertificate()); err != nil {
+			return err
+		}
+		p = &filled
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	passData, err := p.Pass.Marshal()
+	if err != nil {
+		return err
+	}
+	var personalizationData []byte
+	if p.Personalization != nil {
+		if personalizationData, err = p.Personalization.Marshal(); err != nil {
+			return err
+		}
+	}
+	pw := NewSignerWriter(w, signer)
+	if !p.ModTime.IsZero() {
+		pw.SetReproducible(p.ModTime)
+	}
+	if err := p.addFiles(pw, passData, personalizationData); err != nil {
+		pw.abort(err)
+		return err
+	}
+	return pw.Close()
+}
+
+// addFiles adds the description, the images and the localizations of the
+// package to the Writer.
+func (p *Package) addFiles(pw *Writer, passData, personalizationData []byte) error {
+	if err := pw.Add("pass.json", bytes.NewReader(passData)); err != nil {
+		return err
+	}
+	if err := addImages(pw, "", p.Images); err != nil {
+		return err
+	}
+	if personalizationData != nil {
+		if err := pw.Add("personalization.json", bytes.NewReader(personalizationData)); err != nil {
+			return err
+		}
+	}
+	for _, lang := range sortedKeys(p.Localizations) {
+		localization := p.Localizations[lang]
+		dir := lang + ".lproj/"
+		if len(localization.Strings) > 0 {
+			if err := pw.Add(dir+"pass.strings", bytes.NewReader(localization.encodeStrings())); err != nil {
+				return err
+			}
+		}
+		if err := addImages(pw, dir, localization.Images); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bytes validates the package and returns the content of the signed Passbook file.
+func (p *Package) Bytes(signer Signer) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.WriteSigned(&buf, signer); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addImages adds images to the Passbook in the order of their names.
+func addImages(pw *Writer, dir string, images map[string][]byte) error {
+	for _, name := range sortedKeys(images) {
+		if err := pw.Add(dir+name, bytes.NewReader(images[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the sorted keys of the map with string keys.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
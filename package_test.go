@@ -0,0 +1,168 @@
+package passbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSigner returns the same signature for any manifest.
+type testSigner struct{}
+
+func (testSigner) Sign(manifest []byte) ([]byte, error) {
+	return []byte("signature"), nil
+}
+
+// readZip returns the names of the files in the archive in their order and
+// the content of the files.
+func readZip(t *testing.T, data []byte) ([]string, map[string][]byte) {
+	t.Helper()
+	zipr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	files := make(map[string][]byte)
+	for _, file := range zipr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.Name)
+		files[file.Name] = content
+	}
+	return names, files
+}
+
+func TestPackageBytes(t *testing.T) {
+	pkg := &Package{
+		Pass: testPass("generic"),
+		Images: map[string][]byte{
+			"logo.png": []byte("logo"),
+			"icon.png": []byte("icon"),
+		},
+		Localizations: map[string]Localization{
+			"ru": {Strings: map[string]string{"title": "Билет \"A\""}},
+			"en": {
+				Strings: map[string]string{"title": "Ticket"},
+				Images:  map[string][]byte{"logo.png": []byte("en logo")},
+			},
+		},
+		ModTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	data, err := pkg.Bytes(testSigner{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, data)
+	want := []string{"en.lproj/logo.png", "en.lproj/pass.strings", "icon.png", "logo.png",
+		"pass.json", "ru.lproj/pass.strings", "manifest.json", "signature"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("files %q, want %q", names, want)
+	}
+	if s := string(files["ru.lproj/pass.strings"]); s != "\"title\" = \"Билет \\\"A\\\"\";\n" {
+		t.Errorf("unexpected pass.strings: %q", s)
+	}
+	if s := string(files["signature"]); s != "signature" {
+		t.Errorf("unexpected signature: %q", s)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != len(want)-2 {
+		t.Errorf("manifest has %d files, want %d", len(manifest), len(want)-2)
+	}
+	for name, hash := range manifest {
+		sum := sha1.Sum(files[name])
+		if hash != hex.EncodeToString(sum[:]) {
+			t.Errorf("bad hash of %s in manifest", name)
+		}
+	}
+	var pass Pass
+	if err := json.Unmarshal(files["pass.json"], &pass); err != nil {
+		t.Fatal(err)
+	}
+	if pass.SerialNumber != pkg.Pass.SerialNumber || pass.Generic == nil {
+		t.Errorf("unexpected pass.json: %s", files["pass.json"])
+	}
+}
+
+// countSigner counts the signed manifests.
+type countSigner struct{ count int }
+
+func (s *countSigner) Sign(manifest []byte) ([]byte, error) {
+	s.count++
+	return []byte("signature"), nil
+}
+
+func TestPackageWriteError(t *testing.T) {
+	// the names differ only in case, so the second image is not added
+	pkg := &Package{
+		Pass:   testPass("generic"),
+		Images: map[string][]byte{"icon.png": []byte("icon"), "Icon.png": []byte("icon")},
+	}
+	var buf bytes.Buffer
+	var signer countSigner
+	if err := pkg.WriteSigned(&buf, &signer); !errors.Is(err, ErrCaseCollision) {
+		t.Fatalf("error %v", err)
+	}
+	if signer.count != 0 {
+		t.Error("the manifest is signed after the error")
+	}
+	// the archive is finished, but has no manifest and signature
+	names, _ := readZip(t, buf.Bytes())
+	if want := []string{"pass.json", "Icon.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files %q, want %q", names, want)
+	}
+}
+
+func TestPackageValidate(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		edit func(p *Package)
+		err  string
+	}{
+		{"valid", func(p *Package) {}, ""},
+		{"no description", func(p *Package) { p.Pass.Description = "" }, "Empty Description"},
+		{"no icon", func(p *Package) { delete(p.Images, "icon.png") }, "icon.png missed"},
+		{"not png", func(p *Package) { p.Images["logo.jpg"] = nil }, "Bad image name"},
+		{"directory", func(p *Package) { p.Images["en.lproj/logo.png"] = nil }, "Bad image name"},
+		{"language", func(p *Package) { p.Localizations = map[string]Localization{"../en": {}} }, "Bad localization language"},
+		{"localized image", func(p *Package) {
+			p.Localizations = map[string]Localization{"en": {Images: map[string][]byte{"logo": nil}}}
+		}, "Bad image name"},
+	} {
+		pkg := &Package{
+			Pass:   testPass("generic"),
+			Images: map[string][]byte{"icon.png": []byte("icon")},
+		}
+		test.edit(pkg)
+		err := pkg.Validate()
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: %v, want %q", test.name, err, test.err)
+		}
+		if _, err := pkg.Bytes(testSigner{}); err == nil {
+			t.Errorf("%s: invalid package is written", test.name)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package passbook
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
 	"strings"
 )
 
@@ -18,24 +21,55 @@ type Pass struct {
 	// Associated App Keys: Information about an app that is associated with a pass.
 	AppLaunchURL               string `json:"appLaunchURL,omitempty"`               // A URL to be passed to the associated app when launching it.
 	AssociatedStoreIdentifiers []int  `json:"associatedStoreIdentifiers,omitempty"` // A list of iTunes Store item identifiers for the associated apps.
+	AuxiliaryStoreIdentifiers  []int  `json:"auxiliaryStoreIdentifiers,omitempty"`  // A list of App Store item identifiers for the additional apps related to the pass. Available in iOS 18.0.
 	// Companion App Keys: Custom information about a pass provided for a companion app to use.
 	UserInfo map[string]interface{} `json:"userInfo,omitempty"` // Custom information for companion apps. This data is not displayed to the user.
 	// Expiration Keys: Information about when a pass expires and whether it is still valid.
 	// A pass is marked as expired if the current date is after the pass’s expiration date, or if the pass has been explicitly marked as voided.
 	ExpirationDate *W3Time `json:"expirationDate,omitempty"` // Date and time when the pass expires.
 	Voided         bool    `json:"voided,omitempty"`         // Indicates that the pass is void—for example, a one time use coupon that has been redeemed.
+	// Sharing Keys: Information about sharing of the pass.
+	SharingProhibited bool `json:"sharingProhibited,omitempty"` // Prevents the pass from being shared with other people. Available in iOS 11.0.
+	// NFC Keys: Information used for Value Added Service Protocol transactions. Allowed only for store cards.
+	NFC *NFC `json:"nfc,omitempty"` // Information used for Value Added Service Protocol transactions. Available in iOS 9.0.
 	// Relevance Keys: Information about where and when a pass is relevant.
-	Beacons      []Beacon   `json:"beacons,omitempty"`      // Beacons marking locations where the pass is relevant.
-	Locations    []Location `json:"locations,omitempty"`    // Locations where the pass is relevant.
-	MaxDistance  uint       `json:"maxDistance,omitempty"`  // Maximum distance in meters from a relevant latitude and longitude that the pass is relevant.
-	RelevantDate *W3Time    `json:"relevantDate,omitempty"` // Date and time when the pass becomes relevant.
+	Beacons       []Beacon       `json:"beacons,omitempty"`       // Beacons marking locations where the pass is relevant.
+	Locations     []Location     `json:"locations,omitempty"`     // Locations where the pass is relevant.
+	MaxDistance   uint           `json:"maxDistance,omitempty"`   // Maximum distance in meters from a relevant latitude and longitude that the pass is relevant.
+	RelevantDate  *W3Time        `json:"relevantDate,omitempty"`  // Date and time when the pass becomes relevant.
+	RelevantDates []RelevantDate `json:"relevantDates,omitempty"` // Dates and intervals of time when the pass is relevant. Available in iOS 18.0; older versions use relevantDate.
+	// Semantic Keys: Machine-readable metadata that the system uses to offer a pass and suggest related actions.
+	Semantics *SemanticTags `json:"semantics,omitempty"` // Semantic tags of the pass. Available in iOS 12.0.
 	// Visual Appearance Keys: Visual styling and appearance of the pass.
-	Barcode            *Barcode `json:"barcode,omitempty"`            // Information specific to barcodes.
-	BackgroundColor    *Color   `json:"backgroundColor,omitempty"`    // Background color of the pass, specified as an CSS-style RGB triple.
-	ForegroundColor    *Color   `json:"foregroundColor,omitempty"`    // Foreground color of the pass, specified as a CSS-style RGB triple.
-	LabelColor         *Color   `json:"labelColor,omitempty"`         // Color of the label text, specified as a CSS-style RGB triple.
-	LogoText           string   `json:"logoText,omitempty"`           // Text displayed next to the logo on the pass.
-	GroupingIdentifier string   `json:"groupingIdentifier,omitempty"` // Optional for event tickets and boarding passes; otherwise not allowed. Identifier used to group related passes. If a grouping identifier is specified, passes with the same style, pass type identifier, and grouping identifier are displayed as a group. Otherwise, passes are grouped automatically.
+	Barcode            *Barcode  `json:"barcode,omitempty"`            // Information specific to barcodes.
+	Barcodes           []Barcode `json:"barcodes,omitempty"`           // Barcodes of the pass; the first supported format is displayed. Available in iOS 9.0; older versions use barcode.
+	BackgroundColor    *Color    `json:"backgroundColor,omitempty"`    // Background color of the pass, specified as an CSS-style RGB triple.
+	ForegroundColor    *Color    `json:"foregroundColor,omitempty"`    // Foreground color of the pass, specified as a CSS-style RGB triple.
+	LabelColor         *Color    `json:"labelColor,omitempty"`         // Color of the label text, specified as a CSS-style RGB triple.
+	LogoText           string    `json:"logoText,omitempty"`           // Text displayed next to the logo on the pass.
+	SuppressStripShine bool      `json:"suppressStripShine,omitempty"` // Optional for coupons, event tickets and store cards; otherwise not allowed. If true, the strip image is displayed without a shine effect.
+	GroupingIdentifier string    `json:"groupingIdentifier,omitempty"` // Optional for event tickets and boarding passes; otherwise not allowed. Identifier used to group related passes. If a grouping identifier is specified, passes with the same style, pass type identifier, and grouping identifier are displayed as a group. Otherwise, passes are grouped automatically.
+	// Poster Event Ticket Keys: Enhanced layout of event tickets. Allowed only for event tickets. Available in iOS 18.0.
+	PreferredStyleSchemes   []StyleScheme `json:"preferredStyleSchemes,omitempty"`   // Layouts of the event ticket in order of preference; older versions use the regular event ticket layout.
+	EventLogoText           string        `json:"eventLogoText,omitempty"`           // Text displayed next to the logo on the poster event ticket.
+	FooterBackgroundColor   *Color        `json:"footerBackgroundColor,omitempty"`   // Background color of the footer of the poster event ticket, specified as a CSS-style RGB triple.
+	UseAutomaticColors      bool          `json:"useAutomaticColors,omitempty"`      // Colors of the poster event ticket are derived from the background image.
+	SuppressHeaderDarkening bool          `json:"suppressHeaderDarkening,omitempty"` // The header of the poster event ticket is displayed without the darkening gradient.
+	// Event Guide Keys: Links and contacts of the event displayed on the poster event ticket. Allowed only for event tickets. Available in iOS 18.0.
+	AccessibilityURL         string `json:"accessibilityURL,omitempty"`         // URL of the accessibility information of the event.
+	AddOnURL                 string `json:"addOnURL,omitempty"`                 // URL for purchasing the add-ons of the event, such as upgrades.
+	BagPolicyURL             string `json:"bagPolicyURL,omitempty"`             // URL of the bag policy of the venue.
+	ContactVenueEmail        string `json:"contactVenueEmail,omitempty"`        // Email address of the venue.
+	ContactVenuePhoneNumber  string `json:"contactVenuePhoneNumber,omitempty"`  // Phone number of the venue.
+	ContactVenueWebsite      string `json:"contactVenueWebsite,omitempty"`      // URL of the website of the venue.
+	DirectionsInformationURL string `json:"directionsInformationURL,omitempty"` // URL of the directions to the venue.
+	MerchandiseURL           string `json:"merchandiseURL,omitempty"`           // URL for purchasing the merchandise of the event.
+	OrderFoodURL             string `json:"orderFoodURL,omitempty"`             // URL for ordering food at the venue.
+	ParkingInformationURL    string `json:"parkingInformationURL,omitempty"`    // URL of the parking information of the venue.
+	PurchaseParkingURL       string `json:"purchaseParkingURL,omitempty"`       // URL for purchasing the parking at the venue.
+	SellURL                  string `json:"sellURL,omitempty"`                  // URL for selling the ticket.
+	TransferURL              string `json:"transferURL,omitempty"`              // URL for transferring the ticket.
+	TransitInformationURL    string `json:"transitInformationURL,omitempty"`    // URL of the public transit information of the venue.
 	// Style Keys: Specifies the pass style.
 	// Provide exactly one key—the key that corresponds with the pass’s type.
 	Generic      *Fields `json:"generic,omitempty"`      // Information specific to a generic pass.
@@ -46,35 +80,240 @@ type Pass struct {
 	// Web Service Keys: Information used to update passes using the web service.
 	AuthenticationToken string `json:"authenticationToken,omitempty"` // The authentication token to use with the web service. The token must be 16 characters or longer.
 	WebServiceURL       string `json:"webServiceURL,omitempty"`       // The URL of a web service that conforms to the API described in Passbook Web Service Reference.
+	// Unrecognized keys, such as keys of newer versions, preserved as is.
+	Extensions Extensions `json:"-"`
 }
 
-func (p Pass) Marshal() ([]byte, error) {
-	if p.Description == "" {
-		return nil, errors.New("Empty Description")
+// Style returns the name of the pass style key, such as "eventTicket", and the
+// fields of the pass. If no style is defined, the empty name is returned.
+func (p Pass) Style() (string, *Fields) {
+	switch {
+	case p.BoardingPass != nil:
+		return "boardingPass", p.BoardingPass
+	case p.Coupon != nil:
+		return "coupon", p.Coupon
+	case p.EventTicket != nil:
+		return "eventTicket", p.EventTicket
+	case p.StoreCard != nil:
+		return "storeCard", p.StoreCard
+	case p.Generic != nil:
+		return "generic", p.Generic
 	}
+	return "", nil
+}
+
+// Marshal checks the pass and returns its JSON description. If only the
+// relevant dates are defined, the first of them is written as the relevant
+// date too, for older versions. The proximity UUIDs of the beacons are written
+// in the canonical form.
+func (p Pass) Marshal() ([]byte, error) {
 	if p.FormatVersion != 1 {
 		p.FormatVersion = 1
 	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if p.RelevantDate == nil && len(p.RelevantDates) > 0 {
+		// older versions use only the single relevant date
+		start, _, _ := p.RelevantDates[0].interval()
+		relevantDate := W3Time(start)
+		p.RelevantDate = &relevantDate
+	}
+	if len(p.Beacons) > 0 {
+		beacons := make([]Beacon, len(p.Beacons))
+		for i, beacon := range p.Beacons {
+			beacon.ProximityUUID = beacon.uuid()
+			beacons[i] = beacon
+		}
+		p.Beacons = beacons
+	}
+	return json.Marshal(p)
+}
+
+// Validate checks that all the required keys of the pass are set and the
+// optional keys have valid values.
+func (p Pass) Validate() error {
+	if p.Description == "" {
+		return errors.New("Empty Description")
+	}
 	if p.OrganizationName == "" {
-		return nil, errors.New("Empty Organization Name")
+		return errors.New("Empty Organization Name")
 	}
 	if p.PassTypeIdentifier == "" {
-		return nil, errors.New("Empty Pass Type Identifier")
+		return errors.New("Empty Pass Type Identifier")
 	}
 	if p.SerialNumber == "" {
-		return nil, errors.New("Empty Serial Number")
+		return errors.New("Empty Serial Number")
 	}
 	if p.TeamIdentifier == "" {
-		return nil, errors.New("Empty Team Identifier")
+		return errors.New("Empty Team Identifier")
+	}
+	if p.Barcode != nil {
+		if err := p.Barcode.validate(false); err != nil {
+			return err
+		}
+	}
+	for _, barcode := range p.Barcodes {
+		if err := barcode.validate(true); err != nil {
+			return err
+		}
+	}
+	style, fields := p.Style()
+	for _, restricted := range restrictedKeys {
+		if restricted.isSet(p, fields) && !contains(restricted.styles, style) {
+			return fmt.Errorf("Key %s is allowed only for %s passes",
+				restricted.key, strings.Join(restricted.styles, ", "))
+		}
+	}
+	if style == "boardingPass" && fields.TransitType == "" {
+		return errors.New("Transit Type is required for boarding passes")
+	}
+	if err := p.validatePoster(); err != nil {
+		return err
+	}
+	if err := p.validateRelevance(); err != nil {
+		return err
+	}
+	if err := validateRelevantDates(p.RelevantDates); err != nil {
+		return err
 	}
-	if p.AppLaunchURL != "" && len(p.AssociatedStoreIdentifiers) == 0 {
-		return nil, errors.New("Associated Store Identifiers is not defined")
+	if p.NFC != nil {
+		if err := p.NFC.validate(); err != nil {
+			return err
+		}
 	}
-	if len(p.AuthenticationToken) < 16 {
-		return nil, errors.New("The Authentication Token must be 16 characters or longer")
+	for _, id := range p.AssociatedStoreIdentifiers {
+		if id <= 0 {
+			return fmt.Errorf("Bad Associated Store Identifier %d", id)
+		}
+	}
+	for _, id := range p.AuxiliaryStoreIdentifiers {
+		if id <= 0 {
+			return fmt.Errorf("Bad Auxiliary Store Identifier %d", id)
+		}
+	}
+	if err := p.validateEventGuide(); err != nil {
+		return err
+	}
+	if p.AppLaunchURL != "" {
+		if len(p.AssociatedStoreIdentifiers) == 0 {
+			return errors.New("Associated Store Identifiers is not defined")
+		}
+		if u, err := url.Parse(p.AppLaunchURL); err != nil || u.Scheme == "" {
+			return errors.New("The App Launch URL must be an absolute URL")
+		}
+	}
+	if (p.WebServiceURL != "" || p.AuthenticationToken != "") && len(p.AuthenticationToken) < 16 {
+		return errors.New("The Authentication Token must be 16 characters or longer")
 	}
 	if p.WebServiceURL != "" && !strings.HasPrefix(p.WebServiceURL, "https://") {
-		return nil, errors.New("The Web Service URL must use the HTTPS protocol")
+		return errors.New("The Web Service URL must use the HTTPS protocol")
 	}
-	return json.Marshal(p)
+	return nil
+}
+
+// validatePoster checks that the semantic tags required by the poster layout
+// of event tickets are defined.
+func (p Pass) validatePoster() error {
+	var poster bool
+	for _, scheme := range p.PreferredStyleSchemes {
+		switch scheme {
+		case PKStyleSchemePosterEventTicket:
+			poster = true
+		case PKStyleSchemeEventTicket:
+		default:
+			return fmt.Errorf("Unknown style scheme %q", scheme)
+		}
+	}
+	if !poster {
+		return nil
+	}
+	if p.Semantics == nil || p.Semantics.EventName == "" {
+		return errors.New("Poster event ticket requires the event name semantic tag")
+	}
+	if p.Semantics.VenueName == "" {
+		return errors.New("Poster event ticket requires the venue name semantic tag")
+	}
+	if p.Semantics.EventStartDate == nil {
+		return errors.New("Poster event ticket requires the event start date semantic tag")
+	}
+	return nil
+}
+
+// eventGuideKeys lists the event guide keys of the poster event ticket. The
+// keys are allowed only for event tickets and all of them, except the email
+// address and the phone number, are URLs.
+var eventGuideKeys = []struct {
+	key   string
+	url   bool
+	value func(p Pass) string
+}{
+	{"accessibilityURL", true, func(p Pass) string { return p.AccessibilityURL }},
+	{"addOnURL", true, func(p Pass) string { return p.AddOnURL }},
+	{"bagPolicyURL", true, func(p Pass) string { return p.BagPolicyURL }},
+	{"contactVenueEmail", false, func(p Pass) string { return p.ContactVenueEmail }},
+	{"contactVenuePhoneNumber", false, func(p Pass) string { return p.ContactVenuePhoneNumber }},
+	{"contactVenueWebsite", true, func(p Pass) string { return p.ContactVenueWebsite }},
+	{"directionsInformationURL", true, func(p Pass) string { return p.DirectionsInformationURL }},
+	{"merchandiseURL", true, func(p Pass) string { return p.MerchandiseURL }},
+	{"orderFoodURL", true, func(p Pass) string { return p.OrderFoodURL }},
+	{"parkingInformationURL", true, func(p Pass) string { return p.ParkingInformationURL }},
+	{"purchaseParkingURL", true, func(p Pass) string { return p.PurchaseParkingURL }},
+	{"sellURL", true, func(p Pass) string { return p.SellURL }},
+	{"transferURL", true, func(p Pass) string { return p.TransferURL }},
+	{"transitInformationURL", true, func(p Pass) string { return p.TransitInformationURL }},
+}
+
+// validateEventGuide checks that the event guide keys are set only for event
+// tickets and have valid values.
+func (p Pass) validateEventGuide() error {
+	for _, guide := range eventGuideKeys {
+		value := guide.value(p)
+		if value == "" {
+			continue
+		}
+		if p.EventTicket == nil {
+			return fmt.Errorf("Key %s is allowed only for eventTicket passes", guide.key)
+		}
+		if !guide.url {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("The %s must be an absolute HTTP URL", guide.key)
+		}
+	}
+	if p.ContactVenueEmail != "" {
+		if _, err := mail.ParseAddress(p.ContactVenueEmail); err != nil {
+			return fmt.Errorf("Bad Contact Venue Email %q", p.ContactVenueEmail)
+		}
+	}
+	return nil
+}
+
+// restrictedKeys lists the keys of the pass allowed only for some styles.
+var restrictedKeys = []struct {
+	key    string
+	styles []string
+	isSet  func(p Pass, fields *Fields) bool
+}{
+	{"groupingIdentifier", []string{"boardingPass", "eventTicket"},
+		func(p Pass, _ *Fields) bool { return p.GroupingIdentifier != "" }},
+	{"suppressStripShine", []string{"coupon", "eventTicket", "storeCard"},
+		func(p Pass, _ *Fields) bool { return p.SuppressStripShine }},
+	{"nfc", []string{"storeCard"},
+		func(p Pass, _ *Fields) bool { return p.NFC != nil }},
+	{"transitType", []string{"boardingPass"},
+		func(_ Pass, fields *Fields) bool { return fields != nil && fields.TransitType != "" }},
+	{"preferredStyleSchemes", []string{"eventTicket"},
+		func(p Pass, _ *Fields) bool { return len(p.PreferredStyleSchemes) > 0 }},
+	{"eventLogoText", []string{"eventTicket"},
+		func(p Pass, _ *Fields) bool { return p.EventLogoText != "" }},
+	{"footerBackgroundColor", []string{"eventTicket"},
+		func(p Pass, _ *Fields) bool { return p.FooterBackgroundColor != nil }},
+	{"useAutomaticColors", []string{"eventTicket"},
+		func(p Pass, _ *Fields) bool { return p.UseAutomaticColors }},
+	{"suppressHeaderDarkening", []string{"eventTicket"},
+		func(p Pass, _ *Fields) bool { return p.SuppressHeaderDarkening }},
+	{"additionalInfoFields", []string{"eventTicket"},
+		func(_ Pass, fields *Fields) bool { return fields != nil && len(fields.AdditionalInfo) > 0 }},
 }
@@ -1,29 +1,122 @@
 package passbook
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
-
-	"github.com/kr/pretty"
 )
 
+// TestPassRead checks that every pass of the corpus is valid and reading it
+// with json.Unmarshal and writing with Marshal is lossless.
 func TestPassRead(t *testing.T) {
-	var filenames = []string{"Generic" /*"BoardingPass",*/, "Coupon", "Event", "StoreCard"}
+	filenames, err := filepath.Glob("testdata/passes/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filenames) == 0 {
+		t.Fatal("empty corpus")
+	}
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal("Read error:", err)
+			}
+			var pass Pass
+			if err := json.Unmarshal(data, &pass); err != nil {
+				t.Fatal("Parse error:", err)
+			}
+			result, err := pass.Marshal()
+			if err != nil {
+				t.Fatal("Marshal error:", err)
+			}
+			var want, got interface{}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(result, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("round trip is lossy:\nwant %s\ngot  %s", compactJSON(t, data), result)
+			}
+		})
+	}
+}
+
+// compactJSON returns JSON data without insignificant white space.
+func compactJSON(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// FuzzPassRead checks that any description of the pass that was read can be
+// written and read again with the same result.
+func FuzzPassRead(f *testing.F) {
+	filenames, _ := filepath.Glob("testdata/passes/*.json")
 	for _, filename := range filenames {
-		fmt.Println("---", filename)
-		data, err := ioutil.ReadFile(fmt.Sprintf("samples/%s.raw/pass.json", filename))
+		data, err := os.ReadFile(filename)
 		if err != nil {
-			t.Error("Read error:", err)
-			continue
+			f.Fatal(err)
 		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
 		var pass Pass
 		if err := json.Unmarshal(data, &pass); err != nil {
-			pretty.Println(err)
-			t.Error("Parse Error:", err)
-			continue
+			return
+		}
+		pass.Validate()
+		pass.Style()
+		result, err := json.Marshal(pass)
+		if err != nil {
+			t.Fatal("Marshal error:", err)
+		}
+		var again Pass
+		if err := json.Unmarshal(result, &again); err != nil {
+			t.Fatalf("Parse error of written pass: %v\n%s", err, result)
+		}
+		result2, err := json.Marshal(again)
+		if err != nil {
+			t.Fatal("Marshal error:", err)
+		}
+		if !bytes.Equal(result, result2) {
+			t.Errorf("unstable result:\n%s\n%s", result, result2)
+		}
+	})
+}
+
+func TestFieldDataDetectorTypes(t *testing.T) {
+	for _, test := range []struct {
+		detectors []DataDetector
+		want      string
+	}{
+		{nil, `{"key":"k","value":"v"}`},
+		{[]DataDetector{}, `{"key":"k","value":"v","dataDetectorTypes":[]}`},
+		{[]DataDetector{PKDataDetectorTypeLink},
+			`{"key":"k","value":"v","dataDetectorTypes":["PKDataDetectorTypeLink"]}`},
+	} {
+		field := Field{Key: "k", Value: "v", DataDetectorTypes: test.detectors,
+			Extensions: Extensions{"dataDetectorTypes": json.RawMessage(`"x"`)}}
+		data, err := json.Marshal(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.want {
+			t.Errorf("%#v: %s, want %s", test.detectors, data, test.want)
+		}
+		var got Field
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if (got.DataDetectorTypes == nil) != (test.detectors == nil) {
+			t.Errorf("%#v: read as %#v", test.detectors, got.DataDetectorTypes)
 		}
-		pretty.Println(pass)
 	}
 }
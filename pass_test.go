@@ -0,0 +1,257 @@
+package passbook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPass returns the valid pass with the given style for tests.
+func testPass(style string) Pass {
+	pass := Pass{
+		FormatVersion:      1,
+		PassTypeIdentifier: "pass.com.example.test",
+		SerialNumber:       "1",
+		TeamIdentifier:     "A93A5CM278",
+		OrganizationName:   "Example Inc.",
+		Description:        "Test pass",
+	}
+	switch style {
+	case "boardingPass":
+		pass.BoardingPass = &Fields{TransitType: PKTransitTypeAir}
+	case "coupon":
+		pass.Coupon = new(Fields)
+	case "eventTicket":
+		pass.EventTicket = new(Fields)
+	case "storeCard":
+		pass.StoreCard = new(Fields)
+	default:
+		pass.Generic = new(Fields)
+	}
+	return pass
+}
+
+func TestPassValidatePoster(t *testing.T) {
+	start := W3Time(time.Date(2031, 7, 10, 10, 0, 0, 0, time.UTC))
+	for _, test := range []struct {
+		name  string
+		style string
+		edit  func(p *Pass)
+		err   string
+	}{
+		{"eventLogoText", "generic", func(p *Pass) { p.EventLogoText = "Logo" }, "allowed only for eventTicket"},
+		{"useAutomaticColors", "coupon", func(p *Pass) { p.UseAutomaticColors = true }, "allowed only for eventTicket"},
+		{"footerBackgroundColor", "storeCard", func(p *Pass) { p.FooterBackgroundColor = &Color{} }, "allowed only for eventTicket"},
+		{"suppressHeaderDarkening", "generic", func(p *Pass) { p.SuppressHeaderDarkening = true }, "allowed only for eventTicket"},
+		{"bagPolicyURL", "storeCard", func(p *Pass) { p.BagPolicyURL = "https://example.com/bags" }, "allowed only for eventTicket"},
+		{"relative guide URL", "eventTicket", func(p *Pass) { p.OrderFoodURL = "/food" }, "orderFoodURL must be an absolute HTTP URL"},
+		{"guide URL scheme", "eventTicket", func(p *Pass) { p.TransferURL = "example://transfer" }, "transferURL must be an absolute HTTP URL"},
+		{"venue email", "eventTicket", func(p *Pass) { p.ContactVenueEmail = "venue" }, "Contact Venue Email"},
+		{"event guide", "eventTicket", func(p *Pass) {
+			p.ContactVenueEmail = "Venue <venue@example.com>"
+			p.ContactVenuePhoneNumber = "+1 555 0100"
+			p.ParkingInformationURL = "http://example.com/parking"
+			p.SuppressHeaderDarkening = true
+		}, ""},
+		{"additionalInfoFields", "boardingPass", func(p *Pass) {
+			p.BoardingPass.AdditionalInfo = FieldsData{{Key: "info", Value: "text"}}
+		}, "allowed only for eventTicket"},
+		{"eventTicket", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemeEventTicket}
+			p.EventTicket.AdditionalInfo = FieldsData{{Key: "info", Value: "text"}}
+		}, ""},
+		{"unknown scheme", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{"poster"}
+		}, "Unknown style scheme"},
+		{"no semantics", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemePosterEventTicket}
+		}, "event name"},
+		{"no venue", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemePosterEventTicket}
+			p.Semantics = &SemanticTags{EventName: "Festival", EventStartDate: &start}
+		}, "venue name"},
+		{"no start date", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemePosterEventTicket}
+			p.Semantics = &SemanticTags{EventName: "Festival", VenueName: "Park"}
+		}, "start date"},
+		{"poster", "eventTicket", func(p *Pass) {
+			p.PreferredStyleSchemes = []StyleScheme{PKStyleSchemePosterEventTicket, PKStyleSchemeEventTicket}
+			p.Semantics = &SemanticTags{EventName: "Festival", VenueName: "Park", EventStartDate: &start}
+		}, ""},
+	} {
+		pass := testPass(test.style)
+		test.edit(&pass)
+		err := pass.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error %q expected, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestPassKeys(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(der)
+	// keys with the valid values and the styles allowing them; nil means all styles
+	keys := map[string]struct {
+		value  string // JSON object with the key and the keys it depends on
+		styles []string
+	}{
+		"formatVersion":              {`{"formatVersion":1}`, nil},
+		"passTypeIdentifier":         {`{"passTypeIdentifier":"pass.com.example.other"}`, nil},
+		"serialNumber":               {`{"serialNumber":"2"}`, nil},
+		"teamIdentifier":             {`{"teamIdentifier":"B93A5CM278"}`, nil},
+		"organizationName":           {`{"organizationName":"Other Inc."}`, nil},
+		"description":                {`{"description":"Other pass"}`, nil},
+		"appLaunchURL":               {`{"appLaunchURL":"example://pass/1","associatedStoreIdentifiers":[375380948]}`, nil},
+		"associatedStoreIdentifiers": {`{"associatedStoreIdentifiers":[375380948]}`, nil},
+		"auxiliaryStoreIdentifiers":  {`{"auxiliaryStoreIdentifiers":[284882215]}`, nil},
+		"userInfo":                   {`{"userInfo":{"level":1}}`, nil},
+		"expirationDate":             {`{"expirationDate":"2031-12-31T23:59Z"}`, nil},
+		"voided":                     {`{"voided":true}`, nil},
+		"sharingProhibited":          {`{"sharingProhibited":true}`, nil},
+		"nfc":                        {`{"nfc":{"message":"VAS-0001","encryptionPublicKey":"` + publicKey + `"}}`, []string{"storeCard"}},
+		"beacons":                    {`{"beacons":[{"proximityUUID":"E2C56DB5-DFFB-48D2-B060-D0F5A71096E0"}]}`, nil},
+		"locations":                  {`{"locations":[{"latitude":37.33182,"longitude":-122.03118}]}`, nil},
+		"maxDistance":                {`{"maxDistance":100}`, nil},
+		"relevantDate":               {`{"relevantDate":"2031-12-08T13:00Z"}`, nil},
+		"relevantDates":              {`{"relevantDates":[{"date":"2031-12-08T13:00Z"}]}`, nil},
+		"semantics":                  {`{"semantics":{"totalPrice":{"amount":"10","currencyCode":"USD"}}}`, nil},
+		"barcode":                    {`{"barcode":{"format":"PKBarcodeFormatQR","message":"1","messageEncoding":"utf-8"}}`, nil},
+		"barcodes":                   {`{"barcodes":[{"format":"PKBarcodeFormatCode128","message":"1","messageEncoding":"utf-8"}]}`, nil},
+		"backgroundColor":            {`{"backgroundColor":"rgb(0, 0, 0)"}`, nil},
+		"foregroundColor":            {`{"foregroundColor":"rgb(255, 255, 255)"}`, nil},
+		"labelColor":                 {`{"labelColor":"rgb(128, 128, 128)"}`, nil},
+		"logoText":                   {`{"logoText":"Example"}`, nil},
+		"suppressStripShine":         {`{"suppressStripShine":true}`, []string{"coupon", "eventTicket", "storeCard"}},
+		"groupingIdentifier":         {`{"groupingIdentifier":"group"}`, []string{"boardingPass", "eventTicket"}},
+		"preferredStyleSchemes":      {`{"preferredStyleSchemes":["eventTicket"]}`, []string{"eventTicket"}},
+		"eventLogoText":              {`{"eventLogoText":"Example"}`, []string{"eventTicket"}},
+		"footerBackgroundColor":      {`{"footerBackgroundColor":"rgb(0, 0, 0)"}`, []string{"eventTicket"}},
+		"useAutomaticColors":         {`{"useAutomaticColors":true}`, []string{"eventTicket"}},
+		"suppressHeaderDarkening":    {`{"suppressHeaderDarkening":true}`, []string{"eventTicket"}},
+		"accessibilityURL":           {`{"accessibilityURL":"https://example.com/accessibility"}`, []string{"eventTicket"}},
+		"addOnURL":                   {`{"addOnURL":"https://example.com/add-ons"}`, []string{"eventTicket"}},
+		"bagPolicyURL":               {`{"bagPolicyURL":"https://example.com/bags"}`, []string{"eventTicket"}},
+		"contactVenueEmail":          {`{"contactVenueEmail":"venue@example.com"}`, []string{"eventTicket"}},
+		"contactVenuePhoneNumber":    {`{"contactVenuePhoneNumber":"+1 555 0100"}`, []string{"eventTicket"}},
+		"contactVenueWebsite":        {`{"contactVenueWebsite":"https://example.com/"}`, []string{"eventTicket"}},
+		"directionsInformationURL":   {`{"directionsInformationURL":"https://example.com/directions"}`, []string{"eventTicket"}},
+		"merchandiseURL":             {`{"merchandiseURL":"https://example.com/merchandise"}`, []string{"eventTicket"}},
+		"orderFoodURL":               {`{"orderFoodURL":"https://example.com/food"}`, []string{"eventTicket"}},
+		"parkingInformationURL":      {`{"parkingInformationURL":"https://example.com/parking"}`, []string{"eventTicket"}},
+		"purchaseParkingURL":         {`{"purchaseParkingURL":"https://example.com/parking/buy"}`, []string{"eventTicket"}},
+		"sellURL":                    {`{"sellURL":"https://example.com/sell"}`, []string{"eventTicket"}},
+		"transferURL":                {`{"transferURL":"https://example.com/transfer"}`, []string{"eventTicket"}},
+		"transitInformationURL":      {`{"transitInformationURL":"https://example.com/transit"}`, []string{"eventTicket"}},
+		"authenticationToken":        {`{"authenticationToken":"vxwxd7J8AlNNFPS8k0a0FfUFtq0ewzFdc"}`, nil},
+		"webServiceURL":              {`{"webServiceURL":"https://example.com/passes/","authenticationToken":"vxwxd7J8AlNNFPS8k0a0FfUFtq0ewzFdc"}`, nil},
+	}
+	// keys of the pass structure, set in the dictionary of the style
+	fieldsKeys := map[string]struct {
+		value  string
+		styles []string
+	}{
+		"transitType":          {`{"transitType":"PKTransitTypeBus"}`, []string{"boardingPass"}},
+		"primaryFields":        {`{"primaryFields":[{"key":"primary","value":"1"}]}`, nil},
+		"secondaryFields":      {`{"secondaryFields":[{"key":"secondary","value":"2"}]}`, nil},
+		"auxiliaryFields":      {`{"auxiliaryFields":[{"key":"auxiliary","value":"3"}]}`, nil},
+		"backFields":           {`{"backFields":[{"key":"back","value":"4"}]}`, nil},
+		"headerFields":         {`{"headerFields":[{"key":"header","value":"5"}]}`, nil},
+		"additionalInfoFields": {`{"additionalInfoFields":[{"key":"info","value":"6"}]}`, []string{"eventTicket"}},
+	}
+	// all keys of the pass must be tested
+	for _, key := range jsonKeys(reflect.TypeOf(passJSON{})) {
+		if _, ok := keys[key]; !ok && !contains(styleKeys, key) {
+			t.Errorf("key %s is not tested", key)
+		}
+	}
+	for _, key := range jsonKeys(reflect.TypeOf(fieldsJSON{})) {
+		if _, ok := fieldsKeys[key]; !ok {
+			t.Errorf("key %s of pass structure is not tested", key)
+		}
+	}
+	for _, style := range styleKeys {
+		check := func(key, value string, styles []string, inFields bool) {
+			pass := testPass(style)
+			var fields *Fields
+			if inFields {
+				_, fields = pass.Style()
+			}
+			var err error
+			if fields != nil {
+				err = json.Unmarshal([]byte(value), fields)
+			} else {
+				err = json.Unmarshal([]byte(value), &pass)
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", key, err)
+			}
+			err = pass.Validate()
+			switch allowed := styles == nil || contains(styles, style); {
+			case allowed && err != nil:
+				t.Errorf("%s for %s: unexpected error: %v", key, style, err)
+			case !allowed && err == nil:
+				t.Errorf("%s for %s: error expected", key, style)
+			}
+		}
+		for key, test := range keys {
+			check(key, test.value, test.styles, false)
+		}
+		for key, test := range fieldsKeys {
+			check(key, test.value, test.styles, true)
+		}
+	}
+}
+
+func TestPassValidateKeys(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		edit func(p *Pass)
+		err  string
+	}{
+		{"store identifier", func(p *Pass) { p.AssociatedStoreIdentifiers = []int{0} }, "Associated Store Identifier"},
+		{"auxiliary store identifier", func(p *Pass) { p.AuxiliaryStoreIdentifiers = []int{-1} }, "Auxiliary Store Identifier"},
+		{"launch URL without store identifiers", func(p *Pass) { p.AppLaunchURL = "example://pass" }, "not defined"},
+		{"relative launch URL", func(p *Pass) {
+			p.AppLaunchURL = "pass/1"
+			p.AssociatedStoreIdentifiers = []int{375380948}
+		}, "absolute URL"},
+		{"web service URL", func(p *Pass) {
+			p.WebServiceURL = "http://example.com/"
+			p.AuthenticationToken = "vxwxd7J8AlNNFPS8k0a0FfUFtq0ewzFdc"
+		}, "HTTPS"},
+		{"short token", func(p *Pass) { p.AuthenticationToken = "123" }, "16 characters"},
+		{"web service without token", func(p *Pass) { p.WebServiceURL = "https://example.com/" }, "16 characters"},
+		{"empty NFC message", func(p *Pass) { p.NFC = &NFC{} }, "message must be set"},
+		{"long NFC message", func(p *Pass) { p.NFC = &NFC{Message: strings.Repeat("x", 65)} }, "64 bytes"},
+		{"NFC key", func(p *Pass) { p.NFC = &NFC{Message: "VAS", EncryptionPublicKey: "AAAA"} }, "public key"},
+		{"transit type", func(p *Pass) { p.StoreCard, p.BoardingPass = nil, new(Fields) }, "Transit Type"},
+		{"code 128", func(p *Pass) {
+			p.Barcode = &Barcode{Format: PKBarcodeFormatCode128, Message: "1"}
+		}, "only in the barcodes"},
+	} {
+		pass := testPass("storeCard")
+		test.edit(&pass)
+		err := pass.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q expected, got %v", test.name, test.err, err)
+		}
+	}
+}
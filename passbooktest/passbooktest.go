@@ -0,0 +1,238 @@
+// Package passbooktest provides utilities for testing code that creates
+// Apple Passbook files without real Apple certificates.
+//
+// It mints in memory a fake root certificate authority, an intermediate
+// authority similar to Apple WWDR, and pass type certificates with the same
+// extensions and subject attributes as the ones issued by Apple.
+package passbooktest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mdigger/passbook"
+)
+
+var (
+	// oidUserID is the object identifier of the user ID attribute of the subject.
+	oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+	// oidPassTypeExtension is the object identifier of Apple's extension of
+	// pass type certificates.
+	oidPassTypeExtension = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 1, 16}
+	// oidPassSigning is the object identifier of Apple's extended key usage
+	// for signing passes.
+	oidPassSigning = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 4, 14}
+)
+
+// CA is a fake certificate authority that mimics Apple Root CA and Apple WWDR
+// intermediate authority. It is safe for concurrent use, e.g. by parallel tests.
+type CA struct {
+	Root         *x509.Certificate // Root certificate
+	Intermediate *x509.Certificate // Intermediate certificate, similar to Apple WWDR
+	rootKey      *rsa.PrivateKey
+	key          *rsa.PrivateKey // Private key of the intermediate certificate
+	serial       atomic.Int64    // Last serial number of issued certificate
+}
+
+// NewCA creates a new fake certificate authority.
+func NewCA() (*CA, error) {
+	ca := new(CA)
+	var err error
+	if ca.rootKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	root := &x509.Certificate{
+		SerialNumber: ca.nextSerial(),
+		Subject: pkix.Name{
+			CommonName:         "Test Root CA",
+			OrganizationalUnit: []string{"Test Certification Authority"},
+			Organization:       []string{"Test Inc."},
+			Country:            []string{"US"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(20, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if ca.Root, err = createCertificate(root, root, &ca.rootKey.PublicKey, ca.rootKey); err != nil {
+		return nil, err
+	}
+	if ca.key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return nil, err
+	}
+	intermediate := &x509.Certificate{
+		SerialNumber: ca.nextSerial(),
+		Subject: pkix.Name{
+			CommonName:         "Test Worldwide Developer Relations Certification Authority",
+			OrganizationalUnit: []string{"G4"},
+			Organization:       []string{"Test Inc."},
+			Country:            []string{"US"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	if ca.Intermediate, err = createCertificate(intermediate, ca.Root, &ca.key.PublicKey, ca.rootKey); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// Roots returns the pool with the root certificate of the authority for
+// passbook.VerifyOptions.
+func (ca *CA) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Root)
+	return pool
+}
+
+// Intermediates returns the pool with the intermediate certificate of the
+// authority for passbook.VerifyOptions.
+func (ca *CA) Intermediates() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Intermediate)
+	return pool
+}
+
+// VerifyOptions returns the options to verify passes signed by the authority.
+func (ca *CA) VerifyOptions() passbook.VerifyOptions {
+	return passbook.VerifyOptions{Roots: ca.Roots(), Intermediates: ca.Intermediates()}
+}
+
+// NewIdentity issues a new pass type certificate for the pass type identifier
+// and the team identifier, valid for a year, and returns the signing identity
+// with it.
+func (ca *CA) NewIdentity(passTypeIdentifier, teamIdentifier string) (*passbook.Identity, error) {
+	now := time.Now()
+	return ca.NewIdentityValidity(passTypeIdentifier, teamIdentifier, now.Add(-time.Hour), now.AddDate(1, 0, 0))
+}
+
+// NewIdentityValidity is like NewIdentity, but the certificate is valid only
+// in the given period. It allows to test expired certificates.
+func (ca *CA) NewIdentityValidity(passTypeIdentifier, teamIdentifier string,
+	notBefore, notAfter time.Time) (*passbook.Identity, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := asn1.Marshal(passTypeIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: ca.nextSerial(),
+		Subject: pkix.Name{
+			CommonName:         "Pass Type ID: " + passTypeIdentifier,
+			OrganizationalUnit: []string{teamIdentifier},
+			Organization:       []string{"Test Organization"},
+			Country:            []string{"US"},
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: oidUserID, Value: passTypeIdentifier},
+			},
+		},
+		NotBefore:          notBefore,
+		NotAfter:           notAfter,
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{oidPassSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidPassTypeExtension, Value: extension},
+		},
+	}
+	cert, err := createCertificate(leaf, ca.Intermediate, &priv.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	return &passbook.Identity{Certificate: cert, PrivateKey: priv}, nil
+}
+
+// nextSerial returns the serial number for the next certificate.
+func (ca *CA) nextSerial() *big.Int {
+	return big.NewInt(ca.serial.Add(1))
+}
+
+// createCertificate creates the certificate from the template signed by the parent.
+func createCertificate(template, parent *x509.Certificate, pub *rsa.PublicKey,
+	priv *rsa.PrivateKey) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// NewIdentity creates a new fake certificate authority and issues a pass type
+// certificate with it. The test fails on error.
+func NewIdentity(t testing.TB, passTypeIdentifier, teamIdentifier string) (*passbook.Identity, *CA) {
+	t.Helper()
+	ca, err := NewCA()
+	if err != nil {
+		t.Fatal("passbooktest: creating CA:", err)
+	}
+	identity, err := ca.NewIdentity(passTypeIdentifier, teamIdentifier)
+	if err != nil {
+		t.Fatal("passbooktest: creating identity:", err)
+	}
+	return identity, ca
+}
+
+// Build writes the package signed with the identity and returns the content
+// of Passbook file. The test fails on error.
+func Build(t testing.TB, pkg *passbook.Package, signer passbook.Signer) []byte {
+	t.Helper()
+	data, err := pkg.Bytes(signer)
+	if err != nil {
+		t.Fatal("passbooktest: building pass:", err)
+	}
+	return data
+}
+
+// Open reads the content of Passbook file. The test fails on error.
+func Open(t testing.TB, data []byte) *passbook.Reader {
+	t.Helper()
+	reader, err := passbook.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal("passbooktest: opening pass:", err)
+	}
+	return reader
+}
+
+// AssertValid checks the Passbook file with the root certificate of the
+// authority and reports errors found. Warnings are only logged.
+func AssertValid(t testing.TB, data []byte, ca *CA) *passbook.Reader {
+	t.Helper()
+	reader := Open(t, data)
+	for _, problem := range reader.Verify(ca.VerifyOptions()) {
+		if problem.Severity == passbook.SeverityError {
+			t.Error("passbooktest:", problem.Message)
+		} else {
+			t.Log("passbooktest:", problem.Message)
+		}
+	}
+	return reader
+}
+
+// AssertFile checks that the Passbook contains the file with the given content.
+func AssertFile(t testing.TB, reader *passbook.Reader, name string, want []byte) {
+	t.Helper()
+	data, ok := reader.Files[name]
+	switch {
+	case !ok:
+		t.Errorf("passbooktest: %s missed", name)
+	case !bytes.Equal(data, want):
+		t.Errorf("passbooktest: %s has unexpected content", name)
+	}
+}
@@ -0,0 +1,78 @@
+package passbooktest
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"sync"
+	"testing"
+
+	"github.com/mdigger/passbook"
+)
+
+func TestBuild(t *testing.T) {
+	identity, ca := NewIdentity(t, "pass.com.example.test", "A1B2C3D4E5")
+	var icon bytes.Buffer
+	if err := png.Encode(&icon, image.NewRGBA(image.Rect(0, 0, 29, 29))); err != nil {
+		t.Fatal(err)
+	}
+	pkg := &passbook.Package{
+		Pass: passbook.Pass{
+			SerialNumber:     "1",
+			OrganizationName: "Example",
+			Description:      "Test pass",
+			Generic:          &passbook.Fields{},
+		},
+		Images: map[string][]byte{
+			"icon.png":    icon.Bytes(),
+			"icon@2x.png": icon.Bytes(),
+			"logo.png":    icon.Bytes(),
+		},
+	}
+	reader := AssertValid(t, Build(t, pkg, identity), ca)
+	if reader.Pass.PassTypeIdentifier != "pass.com.example.test" ||
+		reader.Pass.TeamIdentifier != "A1B2C3D4E5" {
+		t.Errorf("identifiers are not filled from certificate: %q, %q",
+			reader.Pass.PassTypeIdentifier, reader.Pass.TeamIdentifier)
+	}
+	AssertFile(t, reader, "icon.png", icon.Bytes())
+	// a pass signed by other authority is not trusted
+	other, err := NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := reader.Verify(other.VerifyOptions())
+	if len(problems) == 0 {
+		t.Error("pass signed by other authority is trusted")
+	}
+}
+
+func TestCAConcurrent(t *testing.T) {
+	ca, err := NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 4
+	identities := make([]*passbook.Identity, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			identities[i], errs[i] = ca.NewIdentity("pass.com.example.test", "A1B2C3D4E5")
+		}(i)
+	}
+	wg.Wait()
+	serials := make(map[string]bool)
+	for i, identity := range identities {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		serial := identity.Certificate.SerialNumber.String()
+		if serials[serial] {
+			t.Errorf("serial number %s is issued twice", serial)
+		}
+		serials[serial] = true
+	}
+}
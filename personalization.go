@@ -0,0 +1,112 @@
+package passbook
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Personalization Dictionary: Information about the reward program sign-up,
+// stored in personalization.json. Available in iOS 10.
+type Personalization struct {
+	RequiredPersonalizationFields []PersonalizationField `json:"requiredPersonalizationFields"` // Personal information requested at sign-up.
+	Description                   string                 `json:"description"`                   // Brief description of the program, displayed on the sign-up sheet under the personalization logo.
+	TermsAndConditions            string                 `json:"termsAndConditions,omitempty"`  // Text of the terms and conditions for the program, displayed as a link on the sign-up sheet.
+}
+
+func (p Personalization) Marshal() ([]byte, error) {
+	if len(p.RequiredPersonalizationFields) == 0 {
+		return nil, errors.New("Required personalization fields must be set")
+	}
+	for _, field := range p.RequiredPersonalizationFields {
+		switch field {
+		case PKPassPersonalizationFieldName, PKPassPersonalizationFieldPostalCode,
+			PKPassPersonalizationFieldEmailAddress, PKPassPersonalizationFieldPhoneNumber:
+		default:
+			return nil, errors.New("Personalization field must be one of the following values: " +
+				"PKPassPersonalizationFieldName, PKPassPersonalizationFieldPostalCode, " +
+				"PKPassPersonalizationFieldEmailAddress, PKPassPersonalizationFieldPhoneNumber")
+		}
+	}
+	if p.Description == "" {
+		return nil, errors.New("Description of personalization must be set")
+	}
+	return json.Marshal(p)
+}
+
+// PersonalizationInfo contains the personal information provided by the user at sign-up.
+type PersonalizationInfo struct {
+	FullName       string `json:"fullName,omitempty"`       // User’s full name.
+	GivenName      string `json:"givenName,omitempty"`      // User’s given name.
+	FamilyName     string `json:"familyName,omitempty"`     // User’s family name.
+	EmailAddress   string `json:"emailAddress,omitempty"`   // User’s email address.
+	PhoneNumber    string `json:"phoneNumber,omitempty"`    // User’s phone number.
+	PostalCode     string `json:"postalCode,omitempty"`     // User’s postal code.
+	ISOCountryCode string `json:"ISOCountryCode,omitempty"` // User’s ISO country code.
+}
+
+// PersonalizationRequest is the request that the device sends to the
+// personalize endpoint of the web service when the user signs up.
+type PersonalizationRequest struct {
+	PassTypeIdentifier          string              `json:"-"`                           // Pass type identifier from the request URL.
+	SerialNumber                string              `json:"-"`                           // Serial number of the pass from the request URL.
+	PersonalizationToken        string              `json:"personalizationToken"`        // Token that must be signed and returned in the response.
+	RequiredPersonalizationInfo PersonalizationInfo `json:"requiredPersonalizationInfo"` // Personal information provided by the user.
+}
+
+// PersonalizeHandler returns the handler of the web service endpoint
+//
+//	POST webServiceURL/version/passes/passTypeIdentifier/serialNumber/personalize
+//
+// The handler decodes the request and calls personalize with it, which should
+// store the information of the user, for example to issue a personalized pass.
+// If personalize returns no error, the personalization token is signed with
+// signer and the signature is returned in the response. Errors of personalize
+// and signer are not disclosed to the client: they are written to errorLog or
+// not logged at all if it is nil.
+func PersonalizeHandler(signer Signer, personalize func(*PersonalizationRequest) error, errorLog *log.Logger) http.Handler {
+	logf := func(format string, v ...interface{}) {
+		if errorLog != nil {
+			errorLog.Printf(format, v...)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		// .../passes/passTypeIdentifier/serialNumber/personalize
+		parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+		if len(parts) < 4 || parts[len(parts)-1] != "personalize" || parts[len(parts)-4] != "passes" {
+			http.NotFound(w, r)
+			return
+		}
+		request := &PersonalizationRequest{
+			PassTypeIdentifier: parts[len(parts)-3],
+			SerialNumber:       parts[len(parts)-2],
+		}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil ||
+			request.PersonalizationToken == "" {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if err := personalize(request); err != nil {
+			logf("passbook: personalize %s/%s: %v",
+				request.PassTypeIdentifier, request.SerialNumber, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		signature, err := signer.Sign([]byte(request.PersonalizationToken))
+		if err != nil {
+			logf("passbook: signing personalization token of %s/%s: %v",
+				request.PassTypeIdentifier, request.SerialNumber, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(signature)
+	})
+}
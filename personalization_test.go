@@ -0,0 +1,138 @@
+package passbook
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPersonalizationMarshal(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		p    Personalization
+		err  string
+	}{
+		{"valid", Personalization{
+			RequiredPersonalizationFields: []PersonalizationField{
+				PKPassPersonalizationFieldName, PKPassPersonalizationFieldEmailAddress},
+			Description:        "Join the rewards program",
+			TermsAndConditions: "Terms",
+		}, ""},
+		{"no fields", Personalization{Description: "Join"}, "fields must be set"},
+		{"unknown field", Personalization{
+			RequiredPersonalizationFields: []PersonalizationField{"PKPassPersonalizationFieldAge"},
+			Description:                   "Join",
+		}, "must be one of"},
+		{"no description", Personalization{
+			RequiredPersonalizationFields: []PersonalizationField{PKPassPersonalizationFieldPhoneNumber},
+		}, "Description"},
+	} {
+		data, err := test.p.Marshal()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err == "" && !bytes.Contains(data, []byte(`"requiredPersonalizationFields":["PKPassPersonalizationFieldName","PKPassPersonalizationFieldEmailAddress"]`)):
+			t.Errorf("%s: unexpected JSON %s", test.name, data)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestPackagePersonalization(t *testing.T) {
+	pkg := &Package{
+		Pass: testPass("storeCard"),
+		Images: map[string][]byte{
+			"icon.png":                []byte("icon"),
+			"personalizationLogo.png": []byte("logo"),
+		},
+		Personalization: &Personalization{
+			RequiredPersonalizationFields: []PersonalizationField{PKPassPersonalizationFieldName},
+			Description:                   "Join",
+		},
+	}
+	data, err := pkg.Bytes(testSigner{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, files := readZip(t, data)
+	if !bytes.Contains(files["personalization.json"], []byte(`"description":"Join"`)) {
+		t.Errorf("unexpected personalization.json: %s", files["personalization.json"])
+	}
+	delete(pkg.Images, "personalizationLogo.png")
+	if err := pkg.Validate(); err == nil {
+		t.Error("personalization without logo is valid")
+	}
+	pkg.Images["personalizationLogo.png"] = []byte("logo")
+	pkg.Pass = testPass("coupon")
+	if err := pkg.Validate(); err == nil {
+		t.Error("personalization of coupon is valid")
+	}
+}
+
+func TestPersonalizeHandler(t *testing.T) {
+	var logs bytes.Buffer
+	errorLog := log.New(&logs, "", 0)
+	const path = "/v1/passes/pass.com.example.test/1234/personalize"
+	const body = `{"personalizationToken":"token","requiredPersonalizationInfo":{"fullName":"John Appleseed"}}`
+	var got *PersonalizationRequest
+	var personalizeErr error
+	handler := PersonalizeHandler(testSigner{}, func(r *PersonalizationRequest) error {
+		got = r
+		return personalizeErr
+	}, errorLog)
+	for _, test := range []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		signer   Signer
+		err      error
+		status   int
+		response string
+	}{
+		{"ok", http.MethodPost, path, body, nil, nil, http.StatusOK, "signature"},
+		{"method", http.MethodGet, path, "", nil, nil, http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+		{"path", http.MethodPost, "/v1/passes/pass.com.example.test/1234", body, nil, nil, http.StatusNotFound, "404 page not found\n"},
+		{"bad json", http.MethodPost, path, `{"personalizationToken":`, nil, nil, http.StatusBadRequest, "Bad Request\n"},
+		{"no token", http.MethodPost, path, `{}`, nil, nil, http.StatusBadRequest, "Bad Request\n"},
+		{"personalize error", http.MethodPost, path, body, nil, errors.New("database is down"),
+			http.StatusInternalServerError, "Internal Server Error\n"},
+		{"sign error", http.MethodPost, path, body, errorSigner{errors.New("bad key")}, nil,
+			http.StatusInternalServerError, "Internal Server Error\n"},
+	} {
+		h := handler
+		if test.signer != nil {
+			h = PersonalizeHandler(test.signer, func(*PersonalizationRequest) error { return nil }, errorLog)
+		}
+		personalizeErr = test.err
+		logs.Reset()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+		if w.Code != test.status || w.Body.String() != test.response {
+			t.Errorf("%s: %d %q, want %d %q", test.name, w.Code, w.Body, test.status, test.response)
+		}
+		if w.Code == http.StatusInternalServerError && logs.Len() == 0 {
+			t.Errorf("%s: error is not logged", test.name)
+		}
+	}
+	// without the logger the errors are not logged
+	var global bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&global)
+	personalizeErr = errors.New("database is down")
+	w := httptest.NewRecorder()
+	PersonalizeHandler(testSigner{}, func(*PersonalizationRequest) error { return personalizeErr }, nil).
+		ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	if w.Code != http.StatusInternalServerError || global.Len() != 0 {
+		t.Errorf("without logger: %d, logged %q", w.Code, global.String())
+	}
+	if got == nil || got.PassTypeIdentifier != "pass.com.example.test" || got.SerialNumber != "1234" ||
+		got.PersonalizationToken != "token" || got.RequiredPersonalizationInfo.FullName != "John Appleseed" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+}
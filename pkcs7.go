@@ -0,0 +1,281 @@
+package passbook
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Object identifiers used in PKCS #7 signature.
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// contentInfo is the outer structure of PKCS #7 message.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"` // [0] EXPLICIT
+}
+
+// signedData is the content of PKCS #7 message with signedData type.
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     rawSet       `asn1:"optional,tag:0"`
+	CRLs             rawSet       `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+// rawSet keeps DER encoding of SET OF elements with implicit tag, such as
+// certificates or authenticated attributes.
+type rawSet struct {
+	Raw asn1.RawContent
+}
+
+// content returns the encoded elements of the set.
+func (s rawSet) content() []byte {
+	var v asn1.RawValue
+	if _, err := asn1.Unmarshal(s.Raw, &v); err != nil {
+		return nil
+	}
+	return v.Bytes
+}
+
+// issuerAndSerial identifies the certificate of the signer.
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// signerInfo describes the signature of one signer.
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   rawSet `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes rawSet `asn1:"optional,tag:1"`
+}
+
+// attribute is the authenticated attribute of the signer.
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// setSigningTime replaces the signing time in the authenticated attributes of
+// the signature made by pkcs7.Sign and signs the attributes again with the
+// private key. The attributes are added if the signature has no signing time.
+func setSigningTime(signature []byte, priv *rsa.PrivateKey, signingTime time.Time) ([]byte, error) {
+	sd, err := parseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	si := &sd.SignerInfos[0]
+	hash, err := si.hash()
+	if err != nil {
+		return nil, err
+	}
+	value, err := asn1.Marshal(signingTime.UTC())
+	if err != nil {
+		return nil, err
+	}
+	timeAttribute, err := asn1.Marshal(attribute{
+		Type: oidSigningTime,
+		Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet,
+			IsCompound: true, Bytes: value},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := [][]byte{timeAttribute}
+	for rest := si.AuthenticatedAttributes.content(); len(rest) > 0; {
+		var attr attribute
+		data := rest
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return nil, err
+		}
+		if !attr.Type.Equal(oidSigningTime) {
+			encoded = append(encoded, data[:len(data)-len(rest)])
+		}
+	}
+	// DER requires the elements of SET OF to be sorted
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	attributes := bytes.Join(encoded, nil)
+	if si.AuthenticatedAttributes.Raw, err = asn1.Marshal(asn1.RawValue{
+		Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attributes}); err != nil {
+		return nil, err
+	}
+	// the signature is calculated from attributes encoded as SET OF
+	h := hash.New()
+	h.Write(append([]byte{0x31}, si.AuthenticatedAttributes.Raw[1:]...))
+	if si.EncryptedDigest, err = rsa.SignPKCS1v15(rand.Reader, priv, hash, h.Sum(nil)); err != nil {
+		return nil, err
+	}
+	content, err := asn1.Marshal(*sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0,
+			IsCompound: true, Bytes: content},
+	})
+}
+
+// parseSignature parses DER-encoded PKCS #7 signature.
+func parseSignature(data []byte) (*signedData, error) {
+	var info contentInfo
+	rest, err := asn1.Unmarshal(data, &info)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after signature")
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("signature is not PKCS #7 signed data")
+	}
+	sd := new(signedData)
+	if _, err := asn1.Unmarshal(info.Content.Bytes, sd); err != nil {
+		return nil, err
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, errors.New("signature has no signers")
+	}
+	return sd, nil
+}
+
+// certificates returns all certificates included in the signature.
+func (sd *signedData) certificates() ([]*x509.Certificate, error) {
+	return x509.ParseCertificates(sd.Certificates.content())
+}
+
+// signer returns the certificate of the first signer.
+func (sd *signedData) signer() (*x509.Certificate, error) {
+	certs, err := sd.certificates()
+	if err != nil {
+		return nil, err
+	}
+	ias := sd.SignerInfos[0].IssuerAndSerialNumber
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) &&
+			cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("signer certificate is not included in the signature")
+}
+
+// attributes returns the authenticated attributes of the signer by the string
+// representation of their types.
+func (si signerInfo) attributes() (map[string]asn1.RawValue, error) {
+	result := make(map[string]asn1.RawValue)
+	for rest := si.AuthenticatedAttributes.content(); len(rest) > 0; {
+		var attr attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return nil, err
+		}
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err != nil {
+			return nil, err
+		}
+		result[attr.Type.String()] = value
+	}
+	return result, nil
+}
+
+// hash returns the digest algorithm of the signer. SHA-1 and SHA-256 are supported.
+func (si signerInfo) hash() (crypto.Hash, error) {
+	switch algorithm := si.DigestAlgorithm.Algorithm; {
+	case algorithm.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case algorithm.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	default:
+		return 0, fmt.Errorf("unsupported digest algorithm %v", algorithm)
+	}
+}
+
+// signingTime returns the signing time from the authenticated attributes or
+// zero time if it is not present.
+func (sd *signedData) signingTime() time.Time {
+	attrs, err := sd.SignerInfos[0].attributes()
+	if err != nil {
+		return time.Time{}
+	}
+	value, ok := attrs[oidSigningTime.String()]
+	if !ok {
+		return time.Time{}
+	}
+	var t time.Time
+	if _, err := asn1.Unmarshal(value.FullBytes, &t); err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// verify checks that the signature of the first signer is valid for the
+// content.
+func (sd *signedData) verify(content []byte) error {
+	cert, err := sd.signer()
+	if err != nil {
+		return err
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signer certificate has no RSA public key")
+	}
+	si := sd.SignerInfos[0]
+	hash, err := si.hash()
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(content)
+	digest := h.Sum(nil)
+	if len(si.AuthenticatedAttributes.Raw) > 0 {
+		attrs, err := si.attributes()
+		if err != nil {
+			return err
+		}
+		value, ok := attrs[oidMessageDigest.String()]
+		if !ok {
+			return errors.New("signature has no message digest")
+		}
+		var messageDigest []byte
+		if _, err := asn1.Unmarshal(value.FullBytes, &messageDigest); err != nil {
+			return err
+		}
+		if !bytes.Equal(messageDigest, digest) {
+			return errors.New("message digest does not match the content")
+		}
+		// the signature is calculated from attributes encoded as SET OF
+		encoded := append([]byte{0x31}, si.AuthenticatedAttributes.Raw[1:]...)
+		h = hash.New()
+		h.Write(encoded)
+		digest = h.Sum(nil)
+	}
+	return rsa.VerifyPKCS1v15(pub, hash, digest, si.EncryptedDigest)
+}
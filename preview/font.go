@@ -0,0 +1,93 @@
+package preview
+
+import (
+	"sync"
+
+	"github.com/mdigger/passbook/internal/wallet"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// fontFamily is the list of fonts of the text in SVG images.
+const fontFamily = "Go, 'Helvetica Neue', Helvetica, Arial, sans-serif"
+
+// Parsed Go fonts, regular and bold.
+var (
+	fontsOnce             sync.Once
+	regularFont, boldFont *opentype.Font
+	fontsErr              error
+)
+
+// parseFonts parses the Go fonts once.
+func parseFonts() error {
+	fontsOnce.Do(func() {
+		if regularFont, fontsErr = opentype.Parse(goregular.TTF); fontsErr != nil {
+			return
+		}
+		boldFont, fontsErr = opentype.Parse(gobold.TTF)
+	})
+	return fontsErr
+}
+
+// faceKey identifies the font face by its size in pixels and weight.
+type faceKey struct {
+	size float64
+	bold bool
+}
+
+// faces caches the font faces. The faces are not safe for concurrent use, so
+// every rendering has its own cache. The first error of creating the face is
+// kept in err; the text is measured as empty after it.
+type faces struct {
+	cache map[faceKey]font.Face
+	err   error
+}
+
+// newFaces returns the empty cache of the font faces.
+func newFaces() *faces {
+	return &faces{cache: make(map[faceKey]font.Face)}
+}
+
+// face returns the font face of the given size in pixels or nil on error.
+func (f *faces) face(size float64, bold bool) font.Face {
+	if f.err != nil {
+		return nil
+	}
+	key := faceKey{size, bold}
+	if face, ok := f.cache[key]; ok {
+		return face
+	}
+	parsed := regularFont
+	if bold {
+		parsed = boldFont
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{
+		Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		f.err = err
+		return nil
+	}
+	f.cache[key] = face
+	return face
+}
+
+// measure returns the width of the text in pixels.
+func (f *faces) measure(text string, size float64, bold bool) float64 {
+	face := f.face(size, bold)
+	if face == nil {
+		return 0
+	}
+	return float64(font.MeasureString(face, text)) / 64
+}
+
+// fit returns the text truncated to the given width.
+func (f *faces) fit(text string, size float64, bold bool, width float64) string {
+	return wallet.Fit(text, width, func(s string) float64 { return f.measure(s, size, bold) })
+}
+
+// wrap splits the text to the lines of the given width by the words.
+func (f *faces) wrap(text string, size float64, bold bool, width float64) []string {
+	return wallet.Wrap(text, width, func(s string) float64 { return f.measure(s, size, bold) })
+}
@@ -0,0 +1,479 @@
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/internal/format"
+	"github.com/mdigger/passbook/internal/wallet"
+)
+
+// Sizes of the pass in points.
+const (
+	cardWidth     = 320
+	cardMinHeight = 410 // the barcode is placed at the bottom
+	cardRadius    = 10
+	padding       = 12
+	columnGap     = 8
+)
+
+// Sizes of the fonts in points.
+const (
+	labelSize        = 10
+	valueSize        = 15
+	headerValueSize  = 17
+	logoTextSize     = 17
+	primaryValueSize = 24
+	largeValueSize   = 36
+	altTextSize      = 11
+	backLabelSize    = 13
+	backValueSize    = 15
+)
+
+// elementKind is the kind of the drawn element.
+type elementKind int
+
+// Kinds of the drawn elements.
+const (
+	rectElement    elementKind = iota // Filled rectangle with rounded corners.
+	textElement                       // Line of text.
+	imageElement                      // Image scaled to the bounds.
+	barcodeElement                    // Modules of the barcode scaled to the bounds.
+)
+
+// align is the horizontal alignment of the text.
+type align int
+
+// Alignments of the text.
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// element is the element of the card. The bounds and the sizes are in points.
+type element struct {
+	kind       elementKind
+	x, y, w, h float64                 // Bounds; for text, x is the anchor of the alignment and y is the baseline.
+	radius     float64                 // Radius of the corners of rectangles.
+	color      color.Color             // Color of rectangles, text and barcodes.
+	text       string                  // Text of the line.
+	size       float64                 // Size of the font.
+	bold       bool                    // Bold font.
+	align      align                   // Alignment of the text relative to x.
+	img        image.Image             // Decoded image.
+	data       []byte                  // Content of the image in PNG format.
+	blur       bool                    // Image is blurred, as the background of event tickets.
+	matrix     *passbook.BarcodeMatrix // Modules of the barcode.
+}
+
+// card is the side of the pass laid out as the list of elements.
+type card struct {
+	width, height float64
+	background    color.Color
+	elements      []element
+}
+
+// layout lays out the side of the pass.
+type layout struct {
+	*card
+	pass    *passbook.Pass
+	opts    *Options
+	style   string
+	fields  *passbook.Fields
+	strings map[string]string
+	faces   *faces
+	fg, lbl color.Color
+	y       float64 // Top of the next section.
+}
+
+// errUnknownStyle is returned if the style of the pass is not set.
+var errUnknownStyle = errors.New("Unknown pass style")
+
+// newCard lays out the side of the pass selected by the options.
+func newCard(pass *passbook.Pass, opts *Options) (*card, error) {
+	if err := parseFonts(); err != nil {
+		return nil, err
+	}
+	style, fields := pass.Style()
+	if fields == nil {
+		return nil, errUnknownStyle
+	}
+	l := &layout{
+		card:    &card{width: cardWidth, background: color.White},
+		pass:    pass,
+		opts:    opts,
+		style:   style,
+		fields:  fields,
+		strings: opts.LocalizedStrings(),
+		faces:   newFaces(),
+		fg:      color.Black,
+		y:       padding,
+	}
+	if pass.BackgroundColor != nil {
+		l.background = rgb(pass.BackgroundColor)
+	}
+	if pass.ForegroundColor != nil {
+		l.fg = rgb(pass.ForegroundColor)
+	}
+	l.lbl = l.fg
+	if pass.LabelColor != nil {
+		l.lbl = rgb(pass.LabelColor)
+	}
+	var err error
+	if opts != nil && opts.Side == Back {
+		err = l.back()
+	} else {
+		err = l.front()
+	}
+	if err == nil {
+		err = l.faces.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.card, nil
+}
+
+// rgb converts the color of the pass.
+func rgb(c *passbook.Color) color.Color {
+	return color.RGBA{c.R, c.G, c.B, 0xff}
+}
+
+// image returns the decoded image with the given name or nil, if the pass has
+// no such image.
+func (l *layout) image(name string) (image.Image, []byte, error) {
+	data := l.opts.image(name)
+	if data == nil {
+		return nil, nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s.png: %v", name, err)
+	}
+	return img, data, nil
+}
+
+// addImage adds the image scaled to fit the given size and returns the size
+// of the added image. If the pass has no such image, the size is zero.
+func (l *layout) addImage(name string, x, y, maxWidth, maxHeight float64) (w, h float64, err error) {
+	img, data, err := l.image(name)
+	if img == nil {
+		return 0, 0, err
+	}
+	size := img.Bounds().Size()
+	scale := math.Min(maxWidth/float64(size.X), maxHeight/float64(size.Y))
+	w, h = float64(size.X)*scale, float64(size.Y)*scale
+	l.elements = append(l.elements, element{kind: imageElement,
+		x: x, y: y, w: w, h: h, img: img, data: data})
+	return w, h, nil
+}
+
+// addText adds the line of text truncated to the width.
+func (l *layout) addText(text string, x, baseline, width, size float64, bold bool,
+	c color.Color, a align) {
+	if text == "" {
+		return
+	}
+	text = l.faces.fit(text, size, bold, width)
+	switch a {
+	case alignCenter:
+		x += width / 2
+	case alignRight:
+		x += width
+	}
+	l.elements = append(l.elements, element{kind: textElement,
+		x: x, y: baseline, w: width, text: text, size: size, bold: bold, color: c, align: a})
+}
+
+// fieldAlign returns the alignment of the field. Natural alignment is left.
+func fieldAlign(field passbook.Field) align {
+	switch field.TextAlignment {
+	case passbook.PKTextAlignmentCenter:
+		return alignCenter
+	case passbook.PKTextAlignmentRight:
+		return alignRight
+	}
+	return alignLeft
+}
+
+// front lays out the front of the pass.
+func (l *layout) front() error {
+	background, err := l.backgroundImage()
+	if err != nil {
+		return err
+	}
+	if err := l.header(); err != nil {
+		return err
+	}
+	if err := l.primary(background); err != nil {
+		return err
+	}
+	for _, row := range wallet.FrontRows(l.style, l.fields) {
+		l.row(row)
+	}
+	return l.barcode()
+}
+
+// backgroundImage adds the blurred background image of event tickets and
+// returns true if it is added.
+func (l *layout) backgroundImage() (bool, error) {
+	if l.style != "eventTicket" {
+		return false, nil
+	}
+	img, data, err := l.image("background")
+	if img == nil {
+		return false, err
+	}
+	// the height is fixed after the layout of the whole card
+	l.elements = append(l.elements, element{kind: imageElement,
+		w: cardWidth, img: img, data: data, blur: true})
+	return true, nil
+}
+
+// header lays out the logo, the logo text and the header fields.
+func (l *layout) header() error {
+	const height = 50
+	logoWidth, _, err := l.addImage("logo", padding, l.y, 160, height)
+	if err != nil {
+		return err
+	}
+	// the header fields are placed from the right edge
+	right := float64(cardWidth - padding)
+	header := l.fields.Header
+	if len(header) > 3 {
+		header = header[:3]
+	}
+	for i := len(header) - 1; i >= 0; i-- {
+		label, value := header[i].Display(l.strings)
+		width := math.Max(l.faces.measure(label, labelSize, false),
+			l.faces.measure(value, headerValueSize, false))
+		width = math.Min(width, right-padding-logoWidth-columnGap)
+		l.addText(label, right-width, l.y+labelSize+8, width, labelSize, false, l.lbl, alignRight)
+		l.addText(value, right-width, l.y+labelSize+8+headerValueSize+4, width, headerValueSize, false, l.fg, alignRight)
+		right -= width + columnGap
+	}
+	if l.pass.LogoText != "" {
+		x := padding + logoWidth
+		if logoWidth > 0 {
+			x += columnGap
+		}
+		text := l.pass.LogoText
+		l.addText(format.Localize(l.strings, text), x, l.y+height/2+logoTextSize/3,
+			right-x, logoTextSize, true, l.fg, alignLeft)
+	}
+	l.y += height + columnGap
+	return nil
+}
+
+// primary lays out the primary fields with the strip or the thumbnail image.
+func (l *layout) primary(background bool) error {
+	fields := l.fields.Primary
+	switch {
+	case l.style == "boardingPass":
+		const height = 56
+		width := float64(cardWidth-2*padding-40) / 2
+		for i, field := range fields {
+			if i > 1 {
+				break
+			}
+			label, value := field.Display(l.strings)
+			a := alignLeft
+			x := float64(padding)
+			if i == 1 {
+				a, x = alignRight, cardWidth-padding-width
+			}
+			l.addText(label, x, l.y+labelSize, width, labelSize, false, l.lbl, a)
+			l.addText(value, x, l.y+labelSize+largeValueSize+2, width, largeValueSize, false, l.fg, a)
+		}
+		l.addText("→", padding+width, l.y+labelSize+largeValueSize-4, 40, 28, false, l.fg, alignCenter)
+		l.y += height + columnGap
+		return nil
+	case l.style == "coupon" || l.style == "storeCard" ||
+		(l.style == "eventTicket" && !background && l.opts.image("strip") != nil):
+		maxHeight := 144.0
+		if l.style == "eventTicket" {
+			maxHeight = 98
+		}
+		top := l.y - padding/2
+		img, data, err := l.image("strip")
+		if err != nil {
+			return err
+		}
+		height := 0.0
+		if img != nil {
+			size := img.Bounds().Size()
+			height = math.Min(maxHeight, cardWidth*float64(size.Y)/float64(size.X))
+			l.elements = append(l.elements, element{kind: imageElement,
+				y: top, w: cardWidth, h: height, img: img, data: data})
+		}
+		// the value is displayed above the label on the strip
+		textHeight := 0.0
+		if len(fields) > 0 {
+			label, value := fields[0].Display(l.strings)
+			textHeight = largeValueSize + labelSize + 8
+			baseline := top + math.Max(height, textHeight+2*padding)/2 + largeValueSize/2 - labelSize/2
+			l.addText(value, padding, baseline, cardWidth-2*padding, largeValueSize, false, l.fg, fieldAlign(fields[0]))
+			l.addText(label, padding, baseline+labelSize+6, cardWidth-2*padding, labelSize, false, l.lbl, fieldAlign(fields[0]))
+			textHeight += 2 * padding
+		}
+		l.y = top + math.Max(height, textHeight) + columnGap
+		return nil
+	}
+	// the thumbnail is displayed at the right of the primary fields
+	thumbWidth, thumbHeight, err := l.addImage("thumbnail", 0, l.y, 90, 90)
+	if err != nil {
+		return err
+	}
+	if thumbWidth > 0 {
+		l.elements[len(l.elements)-1].x = cardWidth - padding - thumbWidth
+	}
+	width := cardWidth - 2*padding - thumbWidth
+	if thumbWidth > 0 {
+		width -= columnGap
+	}
+	height := 0.0
+	for i, field := range fields {
+		if i > 0 {
+			break // only one primary field is displayed
+		}
+		label, value := field.Display(l.strings)
+		l.addText(label, padding, l.y+labelSize, width, labelSize, false, l.lbl, fieldAlign(field))
+		l.addText(value, padding, l.y+labelSize+primaryValueSize+4, width, primaryValueSize, false, l.fg, fieldAlign(field))
+		height = labelSize + primaryValueSize + 10
+	}
+	l.y += math.Max(height, thumbHeight) + columnGap
+	return nil
+}
+
+// row lays out the fields in one row of columns of the same width.
+func (l *layout) row(fields passbook.FieldsData) {
+	n := float64(len(fields))
+	width := (cardWidth - 2*padding - (n-1)*columnGap) / n
+	for i, field := range fields {
+		label, value := field.Display(l.strings)
+		x := padding + float64(i)*(width+columnGap)
+		a := fieldAlign(field)
+		if field.TextAlignment == "" && i > 0 && i == len(fields)-1 {
+			a = alignRight // the last column is aligned to the right edge
+		}
+		l.addText(label, x, l.y+labelSize, width, labelSize, false, l.lbl, a)
+		l.addText(value, x, l.y+labelSize+valueSize+4, width, valueSize, false, l.fg, a)
+	}
+	l.y += labelSize + valueSize + 10 + columnGap
+}
+
+// barcode lays out the footer image of boarding passes and the barcode with
+// the alternative text at the bottom of the card.
+func (l *layout) barcode() error {
+	var footer image.Image
+	var footerData []byte
+	if l.style == "boardingPass" {
+		var err error
+		if footer, footerData, err = l.image("footer"); err != nil {
+			return err
+		}
+	}
+	var footerWidth, footerHeight float64
+	if footer != nil {
+		size := footer.Bounds().Size()
+		scale := math.Min(286/float64(size.X), 15/float64(size.Y))
+		footerWidth, footerHeight = float64(size.X)*scale, float64(size.Y)*scale
+	}
+	var matrix *passbook.BarcodeMatrix
+	var width, height, boxPadding, boxWidth, boxHeight float64
+	var altText string
+	if barcode := l.pass.DisplayedBarcode(); barcode != nil {
+		var err error
+		if matrix, err = barcode.Matrix(passbook.ErrorCorrectionMedium); err != nil {
+			return fmt.Errorf("Barcode: %v", err)
+		}
+		// the module size is rounded to the pixels of the largest scale
+		target := 140.0
+		if matrix.Linear || matrix.Width > 2*matrix.Height {
+			target = cardWidth - 4*padding
+		}
+		module := math.Max(math.Floor(target/float64(matrix.Width)*3)/3, 1.0/3)
+		width, height = module*float64(matrix.Width), module*float64(matrix.Height)
+		if matrix.Linear {
+			height = 60
+		}
+		// the box includes the quiet zone
+		boxPadding = math.Max(8, module*float64(matrix.QuietZone))
+		boxWidth, boxHeight = width+2*boxPadding, height+2*boxPadding
+		if altText = format.Localize(l.strings, barcode.AltText); altText != "" {
+			boxHeight += altTextSize + 4
+		}
+	}
+	// the footer and the barcode are placed at the bottom of the card
+	contentHeight := boxHeight
+	if footer != nil {
+		contentHeight += footerHeight + columnGap
+	}
+	l.y = math.Max(l.y, cardMinHeight-padding-contentHeight)
+	if footer != nil {
+		l.elements = append(l.elements, element{kind: imageElement,
+			x: (cardWidth - footerWidth) / 2, y: l.y, w: footerWidth, h: footerHeight,
+			img: footer, data: footerData})
+		l.y += footerHeight + columnGap
+	}
+	if matrix != nil {
+		x := (cardWidth - boxWidth) / 2
+		l.elements = append(l.elements,
+			element{kind: rectElement, x: x, y: l.y, w: boxWidth, h: boxHeight, radius: 4, color: color.White},
+			element{kind: barcodeElement, x: x + boxPadding, y: l.y + boxPadding, w: width, h: height,
+				color: color.Black, matrix: matrix})
+		l.addText(altText, x, l.y+boxPadding+height+altTextSize+2, boxWidth,
+			altTextSize, false, color.Black, alignCenter)
+		l.y += boxHeight
+	}
+	l.finish()
+	return nil
+}
+
+// finish sets the height of the card and of the background image.
+func (l *layout) finish() {
+	l.height = math.Max(l.y+padding, cardMinHeight)
+	for i := range l.elements {
+		if l.elements[i].blur {
+			l.elements[i].h = l.height
+		}
+	}
+}
+
+// back lays out the back fields of the pass as the list.
+func (l *layout) back() error {
+	// the back of the pass is always light
+	l.background = color.RGBA{0xf2, 0xf2, 0xf7, 0xff}
+	gray := color.RGBA{0x8e, 0x8e, 0x93, 0xff}
+	separator := color.RGBA{0xc6, 0xc6, 0xc8, 0xff}
+	width := float64(cardWidth - 2*padding)
+	l.addText(l.pass.OrganizationName, padding, l.y+logoTextSize, width,
+		logoTextSize, true, color.Black, alignLeft)
+	l.addText(l.pass.Description, padding, l.y+logoTextSize+backLabelSize+6, width,
+		backLabelSize, false, gray, alignLeft)
+	l.y += logoTextSize + backLabelSize + 6 + padding
+	for _, field := range l.fields.Back {
+		label, value := field.Display(l.strings)
+		l.elements = append(l.elements, element{kind: rectElement,
+			x: padding, y: l.y, w: width, h: 0.5, color: separator})
+		l.y += padding
+		if label != "" {
+			l.addText(label, padding, l.y+backLabelSize, width, backLabelSize, false, gray, alignLeft)
+			l.y += backLabelSize + 6
+		}
+		for _, line := range l.faces.wrap(value, backValueSize, false, width) {
+			l.addText(line, padding, l.y+backValueSize, width, backValueSize, false, color.Black, alignLeft)
+			l.y += backValueSize + 5
+		}
+		l.y += padding - 5
+	}
+	l.finish()
+	return nil
+}
@@ -0,0 +1,320 @@
+package preview
+
+import (
+	"bytes"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/internal/format"
+	"github.com/mdigger/passbook/internal/wallet"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Sizes of the printed ticket in millimeters.
+const (
+	pdfMargin  = 15
+	pdfPadding = 6
+	pdfGap     = 4
+	pdfRadius  = 4
+	// ptToMM converts the size of the font in points to millimeters.
+	ptToMM = 25.4 / 72
+)
+
+// pdfTicket lays out and draws the printed ticket.
+type pdfTicket struct {
+	pdf     *gofpdf.Fpdf
+	pass    *passbook.Pass
+	opts    *Options
+	style   string
+	fields  *passbook.Fields
+	strings map[string]string
+	fg, lbl passbook.Color
+	x, y, w float64  // Bounds of the content of the ticket.
+	ops     []func() // Drawing of the ticket, deferred until its height is known.
+}
+
+// WritePDF writes the ticket for printing at home to w in PDF format. The A4
+// page contains the logo, the fields and the barcode of the front of the pass
+// in its colors, followed by the back fields, such as terms and conditions,
+// which continue on the next pages if needed. The barcode is drawn as vector
+// graphics. The side of the options is ignored.
+func WritePDF(w io.Writer, pass *passbook.Pass, opts *Options) error {
+	style, fields := pass.Style()
+	if fields == nil {
+		return errUnknownStyle
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(pass.Description, true)
+	pdf.SetCreator(pass.OrganizationName, true)
+	pdf.AddUTF8FontFromBytes("Go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("Go", "B", gobold.TTF)
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	t := &pdfTicket{
+		pdf:     pdf,
+		pass:    pass,
+		style:   style,
+		fields:  fields,
+		strings: opts.LocalizedStrings(),
+		x:       pdfMargin + pdfPadding,
+		y:       pdfMargin + pdfPadding,
+		w:       pageWidth - 2*pdfMargin - 2*pdfPadding,
+	}
+	// the images are printed in the best resolution
+	t.opts = &Options{Scale: 3}
+	if opts != nil {
+		t.opts.Files, t.opts.Language = opts.Files, opts.Language
+	}
+	if pass.ForegroundColor != nil {
+		t.fg = *pass.ForegroundColor
+	}
+	t.lbl = t.fg
+	if pass.LabelColor != nil {
+		t.lbl = *pass.LabelColor
+	}
+	t.header()
+	t.primary()
+	for _, row := range wallet.FrontRows(style, fields) {
+		t.row(row)
+	}
+	t.barcode()
+	t.draw()
+	t.back()
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// draw draws the background of the ticket and its content.
+func (t *pdfTicket) draw() {
+	bg := passbook.Color{R: 0xff, G: 0xff, B: 0xff}
+	if t.pass.BackgroundColor != nil {
+		bg = *t.pass.BackgroundColor
+	}
+	t.pdf.SetFillColor(int(bg.R), int(bg.G), int(bg.B))
+	t.pdf.SetDrawColor(0xc6, 0xc6, 0xc8)
+	t.pdf.RoundedRect(pdfMargin, pdfMargin, t.w+2*pdfPadding, t.y+pdfPadding-pdfMargin,
+		pdfRadius, "1234", "FD")
+	for _, op := range t.ops {
+		op()
+	}
+	t.pdf.SetY(t.y + pdfPadding + pdfGap)
+}
+
+// text adds the line of text truncated to the width.
+func (t *pdfTicket) text(s string, x, y, width, size float64, bold bool, c passbook.Color, a align) {
+	if s == "" {
+		return
+	}
+	t.ops = append(t.ops, func() {
+		t.setFont(size, bold)
+		s := wallet.Fit(s, width, t.pdf.GetStringWidth)
+		switch a {
+		case alignCenter:
+			x += (width - t.pdf.GetStringWidth(s)) / 2
+		case alignRight:
+			x += width - t.pdf.GetStringWidth(s)
+		}
+		t.pdf.SetTextColor(int(c.R), int(c.G), int(c.B))
+		t.pdf.Text(x, y+size*ptToMM*0.8, s) // y is the top of the line
+	})
+}
+
+// setFont sets the Go font of the given size in points.
+func (t *pdfTicket) setFont(size float64, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	t.pdf.SetFont("Go", style, size)
+}
+
+// imageSize registers the image and returns its size scaled to fit the given
+// size. If the pass has no such image, the size is zero.
+func (t *pdfTicket) imageSize(name string, maxWidth, maxHeight float64) (w, h float64) {
+	data := t.opts.image(name)
+	if data == nil {
+		return 0, 0
+	}
+	// the image is decoded completely, as the damaged data is not reported
+	// by the PDF library
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.pdf.SetErrorf("%s.png: %v", name, err)
+		return 0, 0
+	}
+	t.pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(data))
+	size := img.Bounds().Size()
+	scale := math.Min(maxWidth/float64(size.X), maxHeight/float64(size.Y))
+	return float64(size.X) * scale, float64(size.Y) * scale
+}
+
+// image adds the image registered by imageSize.
+func (t *pdfTicket) image(name string, x, y, w, h float64) {
+	t.ops = append(t.ops, func() {
+		t.pdf.ImageOptions(name, x, y, w, h, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	})
+}
+
+// header lays out the logo, the logo text and the header fields.
+func (t *pdfTicket) header() {
+	const height = 15
+	logoWidth, logoHeight := t.imageSize("logo", 48, height)
+	if logoWidth > 0 {
+		t.image("logo", t.x, t.y, logoWidth, logoHeight)
+	}
+	right := t.x + t.w
+	header := t.fields.Header
+	if len(header) > 3 {
+		header = header[:3]
+	}
+	for i := len(header) - 1; i >= 0; i-- {
+		label, value := header[i].Display(t.strings)
+		t.setFont(labelSize-1, false)
+		width := t.pdf.GetStringWidth(label)
+		t.setFont(headerValueSize-4, false)
+		width = math.Min(math.Max(width, t.pdf.GetStringWidth(value)), right-t.x-logoWidth-pdfGap)
+		t.text(label, right-width, t.y+1, width, labelSize-1, false, t.lbl, alignRight)
+		t.text(value, right-width, t.y+6, width, headerValueSize-4, false, t.fg, alignRight)
+		right -= width + pdfGap
+	}
+	if t.pass.LogoText != "" {
+		x := t.x + logoWidth
+		if logoWidth > 0 {
+			x += pdfGap
+		}
+		t.text(format.Localize(t.strings, t.pass.LogoText), x, t.y+height/2-3, right-x,
+			logoTextSize-1, true, t.fg, alignLeft)
+	}
+	t.y += height + pdfGap
+}
+
+// primary lays out the strip image and the primary fields with the thumbnail.
+func (t *pdfTicket) primary() {
+	if t.style == "coupon" || t.style == "storeCard" || t.style == "eventTicket" {
+		if w, h := t.imageSize("strip", t.w, 50); h > 0 {
+			t.image("strip", t.x+(t.w-w)/2, t.y, w, h)
+			t.y += h + pdfGap
+		}
+	}
+	thumbWidth, thumbHeight := 0.0, 0.0
+	if t.style == "generic" || t.style == "eventTicket" {
+		// the thumbnail is placed at the right edge
+		if thumbWidth, thumbHeight = t.imageSize("thumbnail", 30, 30); thumbWidth > 0 {
+			t.image("thumbnail", t.x+t.w-thumbWidth, t.y, thumbWidth, thumbHeight)
+			thumbWidth += pdfGap
+		}
+	}
+	fields := t.fields.Primary
+	if len(fields) > 2 {
+		fields = fields[:2]
+	}
+	height := t.columns(fields, t.w-thumbWidth, largeValueSize-10, true)
+	if t.style == "boardingPass" && len(fields) == 2 {
+		t.text("→", t.x, t.y+4, t.w, largeValueSize-10, false, t.fg, alignCenter)
+	}
+	t.y += math.Max(height, thumbHeight) + pdfGap
+}
+
+// row lays out the row of the secondary or auxiliary fields.
+func (t *pdfTicket) row(fields passbook.FieldsData) {
+	t.y += t.columns(fields, t.w, valueSize-3, false) + pdfGap
+}
+
+// columns lays out the fields in columns of the same width and returns the
+// height of the row.
+func (t *pdfTicket) columns(fields passbook.FieldsData, width, size float64, bold bool) float64 {
+	if len(fields) == 0 {
+		return 0
+	}
+	n := float64(len(fields))
+	columnWidth := (width - (n-1)*pdfGap) / n
+	for i, field := range fields {
+		label, value := field.Display(t.strings)
+		x := t.x + float64(i)*(columnWidth+pdfGap)
+		a := fieldAlign(field)
+		if field.TextAlignment == "" && i > 0 && i == len(fields)-1 {
+			a = alignRight
+		}
+		t.text(label, x, t.y, columnWidth, labelSize-1, false, t.lbl, a)
+		t.text(value, x, t.y+4.5, columnWidth, size, bold, t.fg, a)
+	}
+	return 4.5 + size*ptToMM*1.2
+}
+
+// barcode lays out the barcode with the alternative text on the white box.
+func (t *pdfTicket) barcode() {
+	barcode := t.pass.DisplayedBarcode()
+	if barcode == nil {
+		return
+	}
+	matrix, err := barcode.Matrix(passbook.ErrorCorrectionMedium)
+	if err != nil {
+		t.pdf.SetErrorf("Barcode: %v", err)
+		return
+	}
+	width := 40.0
+	if matrix.Linear || matrix.Width > 2*matrix.Height {
+		width = 100
+	}
+	module := width / float64(matrix.Width)
+	height := module * float64(matrix.Height)
+	if matrix.Linear {
+		height = 20
+	}
+	quiet := module * float64(matrix.QuietZone)
+	boxWidth, boxHeight := width+2*quiet, height+2*quiet
+	altText := format.Localize(t.strings, barcode.AltText)
+	if altText != "" {
+		boxHeight += altTextSize * ptToMM * 1.5
+	}
+	x, y := t.x+(t.w-boxWidth)/2, t.y
+	rowHeight := height / float64(matrix.Height)
+	t.ops = append(t.ops, func() {
+		t.pdf.SetFillColor(0xff, 0xff, 0xff)
+		t.pdf.RoundedRect(x, y, boxWidth, boxHeight, 2, "1234", "F")
+		t.pdf.SetFillColor(0, 0, 0)
+		// the dark modules are drawn as horizontal runs
+		for row := 0; row < matrix.Height; row++ {
+			for col := 0; col < matrix.Width; col++ {
+				if !matrix.At(col, row) {
+					continue
+				}
+				run := 1
+				for matrix.At(col+run, row) {
+					run++
+				}
+				t.pdf.Rect(x+quiet+float64(col)*module, y+quiet+float64(row)*rowHeight,
+					float64(run)*module, rowHeight, "F")
+				col += run
+			}
+		}
+	})
+	t.text(altText, x, y+quiet+height+1, boxWidth, altTextSize, false, passbook.Color{}, alignCenter)
+	t.y += boxHeight
+}
+
+// back prints the back fields below the ticket.
+func (t *pdfTicket) back() {
+	pdf := t.pdf
+	width := t.w + 2*pdfPadding
+	for _, field := range t.fields.Back {
+		label, value := field.Display(t.strings)
+		if label != "" {
+			t.setFont(backLabelSize-4, true)
+			pdf.SetTextColor(0, 0, 0)
+			pdf.MultiCell(width, backLabelSize*ptToMM, label, "", "L", false)
+		}
+		t.setFont(backValueSize-6, false)
+		pdf.SetTextColor(0x3a, 0x3a, 0x3c)
+		pdf.MultiCell(width, backValueSize*ptToMM, value, "", "L", false)
+		pdf.Ln(2)
+	}
+}
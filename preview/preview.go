@@ -0,0 +1,134 @@
+// Package preview draws the approximate look of Apple Wallet passes to PNG
+// and SVG images for reviewing the designs without a device.
+//
+// The front of the pass is drawn with the logo, the header, primary,
+// secondary and auxiliary fields, the strip, thumbnail, background and footer
+// images and the barcode in the colors of the pass. The back of the pass is
+// drawn as the list of the back fields. The layout follows the layout of
+// Wallet only approximately: the sizes of the fonts and the images are the
+// same, but the fonts are different and the text is not localized except by
+// the strings of the pass.
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+
+	"github.com/mdigger/passbook"
+)
+
+// Side of the pass.
+type Side int
+
+// Sides of the pass.
+const (
+	Front Side = iota // Front of the pass with the fields and the barcode.
+	Back              // Back of the pass with the back fields.
+)
+
+// Options of the rendering of the pass.
+type Options struct {
+	Side     Side              // Side of the pass to render.
+	Files    map[string][]byte // Images and localizations by file name, such as Reader.Files or Package.Images.
+	Language string            // Language of the localized images and strings from the files, e.g. "en".
+	Strings  map[string]string // Localized strings; if nil, the strings of the language are read from the files.
+	Scale    int               // Number of pixels in a point of PNG images and the version of images, e.g. logo@2x.png; 2 if zero.
+}
+
+// scale returns the scale from the options.
+func (opts *Options) scale() int {
+	if opts == nil || opts.Scale <= 0 {
+		return 2
+	}
+	if opts.Scale > 3 {
+		return 3
+	}
+	return opts.Scale
+}
+
+// LocalizedStrings returns the localized strings used for rendering: Strings
+// or, if it is nil, the strings of the language from the files. The strings
+// file that can't be parsed is ignored.
+func (opts *Options) LocalizedStrings() map[string]string {
+	if opts == nil {
+		return nil
+	}
+	if opts.Strings != nil || opts.Language == "" {
+		return opts.Strings
+	}
+	data, ok := opts.Files[opts.Language+".lproj/pass.strings"]
+	if !ok {
+		return nil
+	}
+	localized, err := passbook.ParseStrings(data)
+	if err != nil {
+		return nil
+	}
+	return localized
+}
+
+// image returns the content of the image with the given name, e.g. "logo",
+// which is the best suited for the scale. The localized images take
+// precedence.
+func (opts *Options) image(name string) []byte {
+	if opts == nil {
+		return nil
+	}
+	var suffixes []string
+	for scale := opts.scale(); scale <= 3; scale++ {
+		suffixes = append(suffixes, "@"+strconv.Itoa(scale)+"x")
+	}
+	for scale := opts.scale() - 1; scale > 0; scale-- {
+		suffixes = append(suffixes, "@"+strconv.Itoa(scale)+"x")
+	}
+	var dirs []string
+	if opts.Language != "" {
+		dirs = append(dirs, opts.Language+".lproj/")
+	}
+	dirs = append(dirs, "")
+	for _, dir := range dirs {
+		for _, suffix := range suffixes {
+			if suffix == "@1x" {
+				suffix = ""
+			}
+			if data, ok := opts.Files[dir+name+suffix+".png"]; ok {
+				return data
+			}
+		}
+	}
+	return nil
+}
+
+// Image returns the image of the side of the pass.
+func Image(pass *passbook.Pass, opts *Options) (image.Image, error) {
+	c, err := newCard(pass, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.raster(opts.scale())
+}
+
+// WritePNG writes the image of the side of the pass to w in PNG format.
+func WritePNG(w io.Writer, pass *passbook.Pass, opts *Options) error {
+	img, err := Image(pass, opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WriteSVG writes the image of the side of the pass to w in SVG format. The
+// images of the pass are embedded into it.
+func WriteSVG(w io.Writer, pass *passbook.Pass, opts *Options) error {
+	c, err := newCard(pass, opts)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	c.svg(&buf)
+	_, err = buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,231 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mdigger/passbook"
+)
+
+func TestRender(t *testing.T) {
+	names, err := filepath.Glob("../testdata/passes/*.json")
+	if err != nil || len(names) == 0 {
+		t.Fatal("no test passes", err)
+	}
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewGray(image.Rect(0, 0, 160, 50))); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{"logo@2x.png": logo.Bytes(), "strip.png": logo.Bytes()}
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pass := new(passbook.Pass)
+		if err := json.Unmarshal(data, pass); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, side := range []Side{Front, Back} {
+			opts := &Options{Side: side, Files: files}
+			img, err := Image(pass, opts)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if size := img.Bounds().Size(); size.X != 2*cardWidth || size.Y < 2*cardMinHeight {
+				t.Errorf("%s: image size %v", name, size)
+			}
+			var buf bytes.Buffer
+			if err := WriteSVG(&buf, pass, opts); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			for dec := xml.NewDecoder(&buf); ; {
+				if _, err := dec.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s: bad SVG: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+func TestOptionsImage(t *testing.T) {
+	opts := &Options{
+		Language: "ru",
+		Files: map[string][]byte{
+			"logo.png":          []byte("1x"),
+			"logo@3x.png":       []byte("3x"),
+			"ru.lproj/logo.png": []byte("ru"),
+			"icon.png":          []byte("icon"),
+			"strip@2x.png":      []byte("2x"),
+		},
+	}
+	for name, want := range map[string]string{"logo": "ru", "icon": "icon", "strip": "2x", "thumbnail": ""} {
+		if got := string(opts.image(name)); got != want {
+			t.Errorf("%s: %q, want %q", name, got, want)
+		}
+	}
+	opts.Language = ""
+	if got := string(opts.image("logo")); got != "3x" {
+		t.Errorf("logo: %q, want 3x", got)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	data, err := os.ReadFile("../testdata/passes/eventTicket.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass := new(passbook.Pass)
+	if err := json.Unmarshal(data, pass); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, pass, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("bad PDF: %.20q", buf.String())
+	}
+}
+
+// testPDF returns the PDF of the event ticket or an error.
+func testPDF(t *testing.T, change func(pass *passbook.Pass), files map[string][]byte) ([]byte, error) {
+	t.Helper()
+	data, err := os.ReadFile("../testdata/passes/eventTicket.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass := new(passbook.Pass)
+	if err := json.Unmarshal(data, pass); err != nil {
+		t.Fatal(err)
+	}
+	if change != nil {
+		change(pass)
+	}
+	var buf bytes.Buffer
+	err = WritePDF(&buf, pass, &Options{Files: files})
+	return buf.Bytes(), err
+}
+
+func TestWritePDFImages(t *testing.T) {
+	// the images differ, as the same images are written to PDF once
+	images := make([][]byte, 3)
+	for i := range images {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 90, 30+i))); err != nil {
+			t.Fatal(err)
+		}
+		images[i] = buf.Bytes()
+	}
+	for _, test := range []struct {
+		files  map[string][]byte
+		images int
+		err    string
+	}{
+		{nil, 0, ""},
+		{map[string][]byte{"logo@3x.png": images[0]}, 1, ""},
+		{map[string][]byte{"logo.png": images[0], "strip@2x.png": images[1],
+			"thumbnail.png": images[2]}, 3, ""},
+		{map[string][]byte{"logo.png": []byte("not PNG")}, 0, "logo.png"},
+		{map[string][]byte{"strip.png": images[1][:40]}, 0, "strip"},
+	} {
+		data, err := testPDF(t, nil, test.files)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%d images: error %v, want %q", len(test.files), err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := bytes.Count(data, []byte("/Subtype /Image")); n != test.images {
+			t.Errorf("%d images in PDF, want %d", n, test.images)
+		}
+	}
+}
+
+func TestWritePDFBarcodeError(t *testing.T) {
+	_, err := testPDF(t, func(pass *passbook.Pass) {
+		pass.Barcodes = []passbook.Barcode{{Format: passbook.PKBarcodeFormatCode128, Message: "Билет"}}
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Barcode") {
+		t.Errorf("barcode error: %v", err)
+	}
+}
+
+func TestWritePDFBackFields(t *testing.T) {
+	pages := func(data []byte) int {
+		return bytes.Count(data, []byte("/Type /Page\n"))
+	}
+	data, err := testPDF(t, func(pass *passbook.Pass) { pass.EventTicket.Back = nil }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pages(data); n != 1 {
+		t.Errorf("%d pages without back fields", n)
+	}
+	// long terms and conditions continue on the next page
+	data, err = testPDF(t, func(pass *passbook.Pass) {
+		pass.EventTicket.Back = passbook.FieldsData{
+			{Key: "terms", Label: "Terms and Conditions", Value: strings.Repeat("Lorem ipsum dolor sit amet. ", 500)},
+			{Key: "empty", Value: ""},
+		}
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pages(data); n < 2 {
+		t.Errorf("%d pages with long back fields", n)
+	}
+}
+
+func TestLocalizedStrings(t *testing.T) {
+	files := map[string][]byte{"ru.lproj/pass.strings": []byte(`"title" = "Билет";`),
+		"en.lproj/pass.strings": []byte(`"title" = `)}
+	for _, test := range []struct {
+		opts *Options
+		want map[string]string
+	}{
+		{nil, nil},
+		{&Options{Files: files}, nil},
+		{&Options{Files: files, Language: "ru"}, map[string]string{"title": "Билет"}},
+		{&Options{Files: files, Language: "en"}, nil}, // invalid strings are ignored
+		{&Options{Files: files, Language: "ru", Strings: map[string]string{}}, map[string]string{}},
+	} {
+		if got := test.opts.LocalizedStrings(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: %q, want %q", test.opts, got, test.want)
+		}
+	}
+}
+
+func TestFaces(t *testing.T) {
+	if err := parseFonts(); err != nil {
+		t.Fatal(err)
+	}
+	f := newFaces()
+	face := f.face(valueSize, true)
+	if face == nil || f.face(valueSize, true) != face {
+		t.Fatalf("face is not cached: %v", f.err)
+	}
+	if f.measure("Gate", valueSize, false) <= 0 {
+		t.Error("text is not measured")
+	}
+	// after the error the faces are not created and the text is empty
+	f.err = errors.New("bad face")
+	if f.face(labelSize, false) != nil || f.measure("Gate", labelSize, false) != 0 {
+		t.Error("faces are used after error")
+	}
+}
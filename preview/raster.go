@@ -0,0 +1,101 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// roundedMask is the mask of the rectangle with rounded corners. The edges of
+// the corners are anti-aliased.
+type roundedMask struct {
+	r      image.Rectangle
+	radius float64
+}
+
+func (m roundedMask) ColorModel() color.Model { return color.AlphaModel }
+
+func (m roundedMask) Bounds() image.Rectangle { return m.r }
+
+func (m roundedMask) At(x, y int) color.Color {
+	if !(image.Point{x, y}).In(m.r) {
+		return color.Transparent
+	}
+	// distance from the center of the pixel to the inner rectangle
+	px, py := float64(x)+0.5, float64(y)+0.5
+	dx := math.Max(math.Max(float64(m.r.Min.X)+m.radius-px, px-float64(m.r.Max.X)+m.radius), 0)
+	dy := math.Max(math.Max(float64(m.r.Min.Y)+m.radius-py, py-float64(m.r.Max.Y)+m.radius), 0)
+	alpha := math.Min(math.Max(m.radius-math.Hypot(dx, dy)+0.5, 0), 1)
+	return color.Alpha{uint8(alpha * 0xff)}
+}
+
+// raster draws the card to the image with the given number of pixels in a
+// point.
+func (c *card) raster(scale int) (image.Image, error) {
+	s := float64(scale)
+	// pixels returns the rectangle in pixels with rounded edges
+	pixels := func(x, y, w, h float64) image.Rectangle {
+		return image.Rect(int(math.Round(x*s)), int(math.Round(y*s)),
+			int(math.Round((x+w)*s)), int(math.Round((y+h)*s)))
+	}
+	bounds := pixels(0, 0, c.width, c.height)
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, image.NewUniform(c.background), image.Point{}, draw.Src)
+	faces := newFaces()
+	for _, e := range c.elements {
+		r := pixels(e.x, e.y, e.w, e.h)
+		switch e.kind {
+		case rectElement:
+			if r.Empty() {
+				r.Max.Y = r.Min.Y + 1 // hairline
+			}
+			draw.DrawMask(img, r, image.NewUniform(e.color), image.Point{},
+				roundedMask{r, e.radius * s}, r.Min, draw.Over)
+		case imageElement:
+			if e.blur {
+				// the image is blurred by scaling down and up
+				small := image.NewRGBA(image.Rect(0, 0, r.Dx()/16+1, r.Dy()/16+1))
+				draw.ApproxBiLinear.Scale(small, small.Bounds(), e.img, e.img.Bounds(), draw.Src, nil)
+				draw.BiLinear.Scale(img, r, small, small.Bounds(), draw.Src, nil)
+				continue
+			}
+			draw.CatmullRom.Scale(img, r, e.img, e.img.Bounds(), draw.Over, nil)
+		case textElement:
+			face := faces.face(e.size*s, e.bold)
+			if face == nil {
+				return nil, faces.err
+			}
+			x := e.x * s
+			switch width := float64(font.MeasureString(face, e.text)) / 64; e.align {
+			case alignCenter:
+				x -= width / 2
+			case alignRight:
+				x -= width
+			}
+			d := font.Drawer{Dst: img, Src: image.NewUniform(e.color), Face: face,
+				Dot: fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(e.y * s * 64)}}
+			d.DrawString(e.text)
+		case barcodeElement:
+			m := e.matrix
+			src := image.NewUniform(e.color)
+			mw, mh := e.w/float64(m.Width), e.h/float64(m.Height)
+			for y := 0; y < m.Height; y++ {
+				for x := 0; x < m.Width; x++ {
+					if m.At(x, y) {
+						mr := pixels(e.x+float64(x)*mw, e.y+float64(y)*mh, mw, mh)
+						draw.Draw(img, mr, src, image.Point{}, draw.Src)
+					}
+				}
+			}
+		}
+	}
+	// the corners of the card are transparent
+	out := image.NewRGBA(bounds)
+	draw.DrawMask(out, bounds, img, bounds.Min, roundedMask{bounds, cardRadius * s},
+		bounds.Min, draw.Src)
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mdigger/passbook/internal/format"
+)
+
+// svgNumber formats the number of points without trailing zeros.
+func svgNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 32)
+}
+
+// svgText returns the text escaped for XML.
+func svgText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// svg writes the card in SVG format. The sizes are in points.
+func (c *card) svg(w io.Writer) {
+	width, height := svgNumber(c.width), svgNumber(c.height)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`+"\n",
+		width, height)
+	fmt.Fprintf(w, `<defs><clipPath id="card"><rect width="%s" height="%s" rx="%d"/></clipPath>`+
+		`<filter id="blur"><feGaussianBlur stdDeviation="8"/></filter></defs>`+"\n", width, height, cardRadius)
+	fmt.Fprintln(w, `<g clip-path="url(#card)">`)
+	fmt.Fprintf(w, `<rect width="%s" height="%s" fill="%s"/>`+"\n", width, height, format.CSSColor(c.background))
+	for _, e := range c.elements {
+		x, y, w2, h := svgNumber(e.x), svgNumber(e.y), svgNumber(e.w), svgNumber(e.h)
+		switch e.kind {
+		case rectElement:
+			fmt.Fprintf(w, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s" fill="%s"/>`+"\n",
+				x, y, w2, h, svgNumber(e.radius), format.CSSColor(e.color))
+		case imageElement:
+			var filter string
+			if e.blur {
+				filter = ` filter="url(#blur)"`
+			}
+			fmt.Fprintf(w, `<image x="%s" y="%s" width="%s" height="%s" preserveAspectRatio="none"%s href="data:image/png;base64,%s"/>`+"\n",
+				x, y, w2, h, filter, base64.StdEncoding.EncodeToString(e.data))
+		case textElement:
+			anchor := "start"
+			switch e.align {
+			case alignCenter:
+				anchor = "middle"
+			case alignRight:
+				anchor = "end"
+			}
+			weight := "normal"
+			if e.bold {
+				weight = "bold"
+			}
+			fmt.Fprintf(w, `<text x="%s" y="%s" font-family="%s" font-size="%s" font-weight="%s" fill="%s" text-anchor="%s" xml:space="preserve">%s</text>`+"\n",
+				x, y, fontFamily, svgNumber(e.size), weight, format.CSSColor(e.color), anchor, svgText(e.text))
+		case barcodeElement:
+			// the modules are drawn in their own units as horizontal runs
+			m := e.matrix
+			fmt.Fprintf(w, `<g fill="%s" transform="translate(%s %s) scale(%s %s)" shape-rendering="crispEdges">`+"\n",
+				format.CSSColor(e.color), x, y, svgNumber(e.w/float64(m.Width)), svgNumber(e.h/float64(m.Height)))
+			for row := 0; row < m.Height; row++ {
+				for col := 0; col < m.Width; col++ {
+					if !m.At(col, row) {
+						continue
+					}
+					run := 1
+					for m.At(col+run, row) {
+						run++
+					}
+					fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="1"/>`+"\n", col, row, run)
+					col += run
+				}
+			}
+			fmt.Fprintln(w, "</g>")
+		}
+	}
+	fmt.Fprintln(w, "</g>\n</svg>")
+}
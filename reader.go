@@ -0,0 +1,144 @@
+package passbook
+
+import (
+	"archive/zip"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Limits of the Passbook read, which protect from archives that unpack into
+// huge amount of data.
+const (
+	maxFileSize  = 32 << 20 // Size of a single file
+	maxTotalSize = 64 << 20 // Total size of all files
+	maxFiles     = 1000     // Number of files
+)
+
+// ErrLimit is wrapped by the errors of the Passbook files exceeding the limits.
+var ErrLimit = errors.New("limit of Passbook exceeded")
+
+// Reader provides access to the content of Apple Passbook file.
+type Reader struct {
+	Pass      *Pass             // Description of the pass from pass.json
+	Manifest  map[string]string // Hashes of files from manifest.json
+	Signature []byte            // Content of signature file
+	Files     map[string][]byte // Content of all files by name, including pass.json, manifest.json and signature
+}
+
+// OpenReader opens and reads the Passbook file with the given name.
+func OpenReader(name string) (*Reader, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(file, info.Size())
+}
+
+// NewReader reads the Passbook file from r, which is assumed to have the given
+// size in bytes. The pass description is parsed, but not validated.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zipr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if len(zipr.File) > maxFiles {
+		return nil, fmt.Errorf("%w: too many files: %d", ErrLimit, len(zipr.File))
+	}
+	pr := &Reader{Files: make(map[string][]byte, len(zipr.File))}
+	var total int64
+	for _, file := range zipr.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue // directory
+		}
+		if file.UncompressedSize64 > maxFileSize {
+			return nil, fmt.Errorf("%w: file %q is too large", ErrLimit, file.Name)
+		}
+		// the declared size can be wrong, so the read data is limited too
+		limit := int64(maxFileSize)
+		if rest := maxTotalSize - total; rest < limit {
+			limit = rest
+		}
+		data, err := readZipFile(file, limit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		switch size := int64(len(data)); {
+		case size > maxFileSize:
+			return nil, fmt.Errorf("%w: file %q is too large", ErrLimit, file.Name)
+		case size > limit:
+			return nil, fmt.Errorf("%w: total size of files is too large", ErrLimit)
+		}
+		total += int64(len(data))
+		pr.Files[file.Name] = data
+	}
+	passData, ok := pr.Files["pass.json"]
+	if !ok {
+		return nil, ErrNoPass
+	}
+	pr.Pass = new(Pass)
+	if err := json.Unmarshal(passData, pr.Pass); err != nil {
+		return nil, fmt.Errorf("pass.json: %w", err)
+	}
+	if manifestData, ok := pr.Files["manifest.json"]; ok {
+		if err := json.Unmarshal(manifestData, &pr.Manifest); err != nil {
+			return nil, fmt.Errorf("manifest.json: %w", err)
+		}
+	}
+	pr.Signature = pr.Files["signature"]
+	return pr, nil
+}
+
+// readZipFile returns the content of the file from the archive. At most
+// limit+1 bytes are read, so the caller can detect that the file is larger.
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, limit+1))
+}
+
+// Names returns the sorted names of all files in the Passbook.
+func (r *Reader) Names() []string {
+	return sortedKeys(r.Files)
+}
+
+// Localizations returns the sorted language codes of the localizations of the pass.
+func (r *Reader) Localizations() []string {
+	var langs []string
+	for _, name := range r.Names() {
+		dir, _ := path.Split(name)
+		lang := strings.TrimSuffix(dir, ".lproj/")
+		if dir == "" || lang+".lproj/" != dir {
+			continue
+		}
+		if len(langs) == 0 || langs[len(langs)-1] != lang {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// Certificate returns the certificate of the signer of the Passbook.
+func (r *Reader) Certificate() (*x509.Certificate, error) {
+	if r.Signature == nil {
+		return nil, errors.New("signature missed")
+	}
+	sd, err := parseSignature(r.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return sd.signer()
+}
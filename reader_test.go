@@ -0,0 +1,126 @@
+package passbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FuzzReader checks that reading and verifying of any Passbook file doesn't panic.
+func FuzzReader(f *testing.F) {
+	filenames, _ := filepath.Glob("testdata/passes/*.json")
+	for _, filename := range filenames {
+		passData, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		var buf bytes.Buffer
+		zipw := zip.NewWriter(&buf)
+		for name, data := range map[string][]byte{
+			"pass.json":             passData,
+			"manifest.json":         []byte(`{"pass.json":"0000"}`),
+			"signature":             {0x30, 0x03, 0x06, 0x01, 0x00},
+			"en.lproj/pass.strings": []byte(`"a" = "b";`),
+		} {
+			w, err := zipw.Create(name)
+			if err != nil {
+				f.Fatal(err)
+			}
+			w.Write(data)
+		}
+		if err := zipw.Close(); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		r.Verify(VerifyOptions{})
+		r.Certificate()
+		r.Localizations()
+		for _, name := range r.Names() {
+			if filepath.Ext(name) == ".strings" {
+				ParseStrings(r.Files[name])
+			}
+		}
+	})
+}
+
+// zipFiles returns the archive with the given number of files of the given size.
+func zipFiles(t *testing.T, count, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipw := zip.NewWriter(&buf)
+	data := make([]byte, size)
+	for i := 0; i < count; i++ {
+		w, err := zipw.Create(fmt.Sprintf("file%d.png", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zipw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReaderLimits(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"files", zipFiles(t, maxFiles+1, 1), "too many files"},
+		{"file size", zipFiles(t, 1, maxFileSize+1), "is too large"},
+		{"total size", zipFiles(t, maxTotalSize/maxFileSize+1, maxFileSize), "total size"},
+		{"within limits", zipFiles(t, maxFiles, 1), ErrNoPass.Error()},
+	} {
+		_, err := NewReader(bytes.NewReader(test.data), int64(len(test.data)))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: %v, want %q", test.name, err, test.err)
+		}
+		if limit := test.err != ErrNoPass.Error(); errors.Is(err, ErrLimit) != limit {
+			t.Errorf("%s: %v is not ErrLimit", test.name, err)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"pass.json":     {"pass.json": `{"formatVersion":`},
+		"manifest.json": {"pass.json": `{}`, "manifest.json": `[]`},
+	} {
+		var buf bytes.Buffer
+		zipw := zip.NewWriter(&buf)
+		for name, data := range files {
+			w, err := zipw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(data))
+		}
+		if err := zipw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+			t.Errorf("%s: %v", name, err)
+		}
+		// the errors of JSON decoding are wrapped
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+			t.Errorf("%s: %T is not wrapped", name, err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package passbook
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxRelevanceEntries is the number of locations and beacons monitored by
+// devices; the following entries are ignored.
+const maxRelevanceEntries = 10
+
+// nearLocationDistance is the distance in meters between the locations that
+// are considered the same.
+const nearLocationDistance = 10
+
+// validateRelevance checks the coordinates of the locations and the
+// identifiers of the beacons.
+func (p Pass) validateRelevance() error {
+	for i, location := range p.Locations {
+		if err := location.validate(); err != nil {
+			return fmt.Errorf("%v (locations[%d])", err, i)
+		}
+	}
+	for i, beacon := range p.Beacons {
+		if err := beacon.validate(); err != nil {
+			return fmt.Errorf("%v (beacons[%d])", err, i)
+		}
+	}
+	return nil
+}
+
+// Warnings returns the descriptions of the problems of the pass that don't
+// make it invalid, but should be fixed: locations and beacons ignored by
+// devices, duplicates and barcodes unsupported by older devices.
+func (p Pass) Warnings() []string {
+	var warnings []string
+	if len(p.Locations) > maxRelevanceEntries {
+		warnings = append(warnings, fmt.Sprintf("only the first %d of %d locations are used",
+			maxRelevanceEntries, len(p.Locations)))
+	}
+	if len(p.Beacons) > maxRelevanceEntries {
+		warnings = append(warnings, fmt.Sprintf("only the first %d of %d beacons are used",
+			maxRelevanceEntries, len(p.Beacons)))
+	}
+	for i, j := range p.duplicateLocations() {
+		if j >= 0 {
+			warnings = append(warnings, fmt.Sprintf("locations[%d] is within %d m of locations[%d]",
+				i, nearLocationDistance, j))
+		}
+	}
+	for i, j := range p.duplicateBeacons() {
+		if j >= 0 {
+			warnings = append(warnings, fmt.Sprintf("beacons[%d] duplicates beacons[%d]", i, j))
+		}
+	}
+	warnings = append(warnings, p.barcodeWarnings()...)
+	if p.MaxDistance > 0 && len(p.Locations) == 0 {
+		warnings = append(warnings, "maxDistance is ignored without locations")
+	}
+	return warnings
+}
+
+// Deduplicate removes the locations near the previous ones and the beacons
+// with the same identifiers as the previous ones. It returns the number of
+// removed entries.
+func (p *Pass) Deduplicate() int {
+	var removed int
+	var locations []Location
+	for i, j := range p.duplicateLocations() {
+		if j < 0 {
+			locations = append(locations, p.Locations[i])
+		} else {
+			removed++
+		}
+	}
+	var beacons []Beacon
+	for i, j := range p.duplicateBeacons() {
+		if j < 0 {
+			beacons = append(beacons, p.Beacons[i])
+		} else {
+			removed++
+		}
+	}
+	if removed > 0 {
+		p.Locations, p.Beacons = locations, beacons
+	}
+	return removed
+}
+
+// duplicateLocations returns the index of the previous near location for each
+// location or -1 if there is no such location.
+func (p Pass) duplicateLocations() []int {
+	duplicates := make([]int, len(p.Locations))
+	for i, location := range p.Locations {
+		duplicates[i] = -1
+		for j := 0; j < i; j++ {
+			if duplicates[j] < 0 && location.Distance(p.Locations[j].Latitude,
+				p.Locations[j].Longitude) <= nearLocationDistance {
+				duplicates[i] = j
+				break
+			}
+		}
+	}
+	return duplicates
+}
+
+// duplicateBeacons returns the index of the previous beacon with the same
+// identifiers for each beacon or -1 if there is no such beacon.
+func (p Pass) duplicateBeacons() []int {
+	duplicates := make([]int, len(p.Beacons))
+	for i, beacon := range p.Beacons {
+		duplicates[i] = -1
+		for j := 0; j < i; j++ {
+			prev := p.Beacons[j]
+			if beacon.uuid() == prev.uuid() &&
+				beacon.Major == prev.Major && beacon.Minor == prev.Minor {
+				duplicates[i] = j
+				break
+			}
+		}
+	}
+	return duplicates
+}
+
+// Default relevance radii of the locations in meters.
+const (
+	smallRadius = 100
+	largeRadius = 1000
+)
+
+// relevantDateWindow is the time before and after the relevant date when the
+// pass is relevant.
+const relevantDateWindow = time.Hour
+
+// DeviceState describes the simulated state of the device to check the
+// relevance of the pass.
+type DeviceState struct {
+	Time     time.Time // Current time of the device.
+	Location *Location // Current location of the device; nil if it is unknown.
+	Beacons  []Beacon  // Beacons near the device.
+}
+
+// Relevance describes whether the pass is relevant and why.
+type Relevance struct {
+	Relevant bool      // The pass is shown on the lock screen.
+	Reason   string    // Description of the cause of relevance or irrelevance.
+	Date     *W3Time   // Relevant date or start of the relevant interval that caused relevance.
+	Location *Location // Location that caused relevance.
+	Beacon   *Beacon   // Beacon that caused relevance.
+	Distance float64   // Distance in meters to the nearest location of the pass.
+}
+
+// Radius returns the distance in meters from the locations of the pass within
+// which the pass is relevant: the default radius of the pass style, reduced
+// by the maximum distance.
+func (p Pass) Radius() float64 {
+	radius := float64(smallRadius)
+	if style, _ := p.Style(); style == "boardingPass" || style == "eventTicket" {
+		radius = largeRadius
+	}
+	if p.MaxDistance > 0 && float64(p.MaxDistance) < radius {
+		radius = float64(p.MaxDistance)
+	}
+	return radius
+}
+
+// Relevance simulates Wallet and returns whether the pass is relevant for the
+// device in the given state. Coupons and store cards are relevant by location
+// only. For other styles with both the relevant dates and the locations or
+// beacons, the device must be near the location at the relevant time.
+func (p Pass) Relevance(state DeviceState) Relevance {
+	if p.Voided {
+		return Relevance{Reason: "pass is voided"}
+	}
+	if p.ExpirationDate != nil && state.Time.After(time.Time(*p.ExpirationDate)) {
+		return Relevance{Reason: "pass is expired"}
+	}
+	style, _ := p.Style()
+	var dated, dateMatch bool
+	var result Relevance
+	if style != "coupon" && style != "storeCard" {
+		dated, dateMatch, result = p.relevantDate(state.Time)
+	}
+	placed := len(p.Locations) > 0 || len(p.Beacons) > 0
+	if !dated && !placed {
+		return Relevance{Reason: "pass has no relevance keys"}
+	}
+	if dated && !dateMatch {
+		return Relevance{Reason: "device time is not near relevant dates"}
+	}
+	if !placed {
+		result.Relevant = true
+		return result
+	}
+	place := p.relevantPlace(state)
+	if !place.Relevant {
+		return place
+	}
+	place.Date = result.Date
+	if dated {
+		place.Reason = result.Reason + " and " + place.Reason
+	}
+	return place
+}
+
+// relevantDate checks the relevant dates of the pass. It returns whether the
+// pass has the dates and whether the time matches one of them.
+func (p Pass) relevantDate(now time.Time) (dated, match bool, result Relevance) {
+	if len(p.RelevantDates) > 0 {
+		for i, date := range p.RelevantDates {
+			start, end, err := date.interval()
+			if err != nil {
+				continue
+			}
+			if date.Date != nil {
+				start, end = start.Add(-relevantDateWindow), end.Add(relevantDateWindow)
+			}
+			if !now.Before(start) && !now.After(end) {
+				w3 := date.Date
+				if w3 == nil {
+					w3 = date.StartDate
+				}
+				return true, true, Relevance{Date: w3, Reason: fmt.Sprintf("relevantDates[%d]", i)}
+			}
+		}
+		return true, false, Relevance{}
+	}
+	if p.RelevantDate == nil {
+		return false, false, Relevance{}
+	}
+	date := time.Time(*p.RelevantDate)
+	if now.Before(date.Add(-relevantDateWindow)) || now.After(date.Add(relevantDateWindow)) {
+		return true, false, Relevance{}
+	}
+	return true, true, Relevance{Date: p.RelevantDate, Reason: "relevantDate"}
+}
+
+// relevantPlace checks the beacons and the locations of the pass. Only the
+// entries monitored by devices are checked.
+func (p Pass) relevantPlace(state DeviceState) Relevance {
+	for i, beacon := range p.Beacons {
+		if i == maxRelevanceEntries {
+			break
+		}
+		for _, near := range state.Beacons {
+			if beacon.uuid() == near.uuid() &&
+				(beacon.Major == 0 || beacon.Major == near.Major) &&
+				(beacon.Minor == 0 || beacon.Minor == near.Minor) {
+				beacon := beacon
+				return Relevance{Relevant: true, Beacon: &beacon,
+					Reason: fmt.Sprintf("beacons[%d] is nearby", i)}
+			}
+		}
+	}
+	if state.Location == nil {
+		return Relevance{Reason: "device is not near relevant beacons and its location is unknown"}
+	}
+	radius := p.Radius()
+	nearest := -1
+	var nearestDistance float64
+	for i, location := range p.Locations {
+		if i == maxRelevanceEntries {
+			break
+		}
+		d := location.Distance(state.Location.Latitude, state.Location.Longitude)
+		if nearest < 0 || d < nearestDistance {
+			nearest, nearestDistance = i, d
+		}
+	}
+	if nearest < 0 || nearestDistance > radius {
+		reason := "device is not near relevant locations or beacons"
+		if nearest >= 0 {
+			reason = fmt.Sprintf("nearest location is locations[%d] at %.0f m, radius is %.0f m",
+				nearest, nearestDistance, radius)
+		}
+		return Relevance{Reason: reason, Distance: nearestDistance}
+	}
+	location := p.Locations[nearest]
+	return Relevance{Relevant: true, Location: &location, Distance: nearestDistance,
+		Reason: fmt.Sprintf("locations[%d] is at %.0f m within radius %.0f m", nearest, nearestDistance, radius)}
+}
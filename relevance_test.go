@@ -0,0 +1,143 @@
+package passbook
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassRelevance(t *testing.T) {
+	pass := testPass("generic")
+	pass.Locations = []Location{
+		{Latitude: 37.33182, Longitude: -122.03118},
+		{Latitude: 37.33185, Longitude: -122.03120}, // ~4 m from the first
+		{Latitude: 37.6189722, Longitude: -122.3748889},
+	}
+	pass.Beacons = []Beacon{
+		{ProximityUUID: "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", Major: 1},
+		{ProximityUUID: "e2c56db5-dffb-48d2-b060-d0f5a71096e0", Major: 1},
+		{ProximityUUID: "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", Major: 2},
+		{ProximityUUID: "{E2C56DB5-DFFB-48D2-B060-D0F5A71096E0}", Major: 2},
+	}
+	if err := pass.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	warnings := pass.Warnings()
+	if len(warnings) != 3 || !strings.Contains(warnings[0], "locations[1]") ||
+		!strings.Contains(warnings[1], "beacons[1] duplicates beacons[0]") ||
+		!strings.Contains(warnings[2], "beacons[3] duplicates beacons[2]") {
+		t.Errorf("warnings: %q", warnings)
+	}
+	if n := pass.Deduplicate(); n != 3 || len(pass.Locations) != 2 || len(pass.Beacons) != 2 {
+		t.Errorf("deduplicated %d: %v, %v", n, pass.Locations, pass.Beacons)
+	}
+	if warnings := pass.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings after deduplication: %q", warnings)
+	}
+	for i := 0; i < 11; i++ {
+		pass.Locations = append(pass.Locations, Location{Latitude: float64(i), Longitude: 10})
+	}
+	if warnings := pass.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "first 10 of 13") {
+		t.Errorf("warnings: %q", warnings)
+	}
+
+	for _, test := range []struct {
+		edit func(p *Pass)
+		err  string
+	}{
+		{func(p *Pass) { p.Locations = []Location{{Latitude: 91}} }, "Latitude"},
+		{func(p *Pass) { p.Locations = []Location{{Latitude: -90.5}} }, "Latitude"},
+		{func(p *Pass) { p.Locations = []Location{{Longitude: 180.1}} }, "Longitude"},
+		{func(p *Pass) { p.Beacons = []Beacon{{ProximityUUID: "E2C56DB5DFFB48D2B060D0F5A71096E0"}} }, "Proximity UUID"},
+		{func(p *Pass) { p.Beacons = []Beacon{{ProximityUUID: "{E2C56DB5-DFFB-48D2-B060-D0F5A71096E0"}} }, "Proximity UUID"},
+		{func(p *Pass) { p.Beacons = []Beacon{{ProximityUUID: "G2C56DB5-DFFB-48D2-B060-D0F5A71096E0"}} }, "Proximity UUID"},
+	} {
+		pass := testPass("generic")
+		test.edit(&pass)
+		if err := pass.Validate(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q expected, got %v", fmt.Sprint(pass.Locations, pass.Beacons), test.err, err)
+		}
+	}
+}
+
+func TestPassRelevanceSimulation(t *testing.T) {
+	date := W3Time(time.Date(2031, 12, 8, 13, 0, 0, 0, time.UTC))
+	store := Location{Latitude: 37.33182, Longitude: -122.03118}
+	near := &Location{Latitude: 37.3320, Longitude: -122.0312} // ~20 m
+	far := &Location{Latitude: 37.3350, Longitude: -122.0312}  // ~350 m
+	beacon := Beacon{ProximityUUID: "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", Major: 1}
+	for _, test := range []struct {
+		name     string
+		style    string
+		edit     func(p *Pass)
+		state    DeviceState
+		relevant bool
+	}{
+		{"near store", "storeCard", func(p *Pass) { p.Locations = []Location{store} },
+			DeviceState{Location: near}, true},
+		{"small radius", "storeCard", func(p *Pass) { p.Locations = []Location{store} },
+			DeviceState{Location: far}, false},
+		{"large radius", "boardingPass", func(p *Pass) { p.Locations = []Location{store} },
+			DeviceState{Location: far}, true},
+		{"max distance", "boardingPass", func(p *Pass) {
+			p.Locations, p.MaxDistance = []Location{store}, 300
+		}, DeviceState{Location: far}, false},
+		{"beacon", "coupon", func(p *Pass) { p.Beacons = []Beacon{beacon} },
+			DeviceState{Beacons: []Beacon{{ProximityUUID: beacon.ProximityUUID, Major: 1, Minor: 7}}}, true},
+		{"other beacon", "coupon", func(p *Pass) { p.Beacons = []Beacon{beacon} },
+			DeviceState{Beacons: []Beacon{{ProximityUUID: beacon.ProximityUUID, Major: 2}}}, false},
+		{"date", "eventTicket", func(p *Pass) { p.RelevantDate = &date },
+			DeviceState{Time: time.Time(date).Add(-30 * time.Minute)}, true},
+		{"late", "eventTicket", func(p *Pass) { p.RelevantDate = &date },
+			DeviceState{Time: time.Time(date).Add(2 * time.Hour)}, false},
+		{"date not supported", "coupon", func(p *Pass) { p.RelevantDate = &date },
+			DeviceState{Time: time.Time(date)}, false},
+		{"date and far", "eventTicket", func(p *Pass) {
+			p.RelevantDate, p.Locations, p.MaxDistance = &date, []Location{store}, 100
+		}, DeviceState{Time: time.Time(date), Location: far}, false},
+		{"interval", "generic", func(p *Pass) {
+			end := W3Time(time.Time(date).Add(48 * time.Hour))
+			p.RelevantDates = []RelevantDate{{StartDate: &date, EndDate: &end}}
+		}, DeviceState{Time: time.Time(date).Add(24 * time.Hour)}, true},
+		{"voided", "storeCard", func(p *Pass) { p.Locations, p.Voided = []Location{store}, true },
+			DeviceState{Location: near}, false},
+	} {
+		pass := testPass(test.style)
+		test.edit(&pass)
+		if result := pass.Relevance(test.state); result.Relevant != test.relevant {
+			t.Errorf("%s: relevant %v: %s", test.name, result.Relevant, result.Reason)
+		}
+	}
+}
+
+func TestBeaconProximityUUID(t *testing.T) {
+	const uuid = "e2c56db5-dffb-48d2-b060-d0f5a71096e0"
+	for _, s := range []string{uuid, "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", "{E2C56DB5-dffb-48D2-B060-D0F5A71096E0}"} {
+		beacon := Beacon{ProximityUUID: s, Major: 1}
+		data, err := beacon.Marshal()
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if want := `{"proximityUUID":"` + uuid + `","major":1}`; string(data) != want {
+			t.Errorf("%s: %s, want %s", s, data, want)
+		}
+	}
+	pass := testPass("generic")
+	pass.Beacons = []Beacon{{ProximityUUID: "{E2C56DB5-DFFB-48D2-B060-D0F5A71096E0}"}}
+	data, err := pass.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"proximityUUID":"`+uuid+`"`) {
+		t.Errorf("proximity UUID is not canonical: %s", data)
+	}
+	if pass.Beacons[0].ProximityUUID[0] != '{' {
+		t.Errorf("pass is changed: %v", pass.Beacons)
+	}
+	// the beacons nearby are compared in the canonical form too
+	state := DeviceState{Beacons: []Beacon{{ProximityUUID: uuid}}}
+	if relevance := pass.relevantPlace(state); !relevance.Relevant {
+		t.Errorf("beacon is not relevant: %+v", relevance)
+	}
+}
@@ -0,0 +1,51 @@
+package passbook
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RelevantDate Dictionary: A date or the interval of time when the pass is
+// relevant. Available in iOS 18.0.
+type RelevantDate struct {
+	Date      *W3Time `json:"date,omitempty"`      // Date and time when the pass becomes relevant.
+	StartDate *W3Time `json:"startDate,omitempty"` // Date and time when the interval of relevance starts.
+	EndDate   *W3Time `json:"endDate,omitempty"`   // Date and time when the interval of relevance ends.
+}
+
+// interval returns the start and the end of the relevance. The date is
+// returned as the interval of zero length.
+func (d RelevantDate) interval() (start, end time.Time, err error) {
+	switch {
+	case d.Date != nil && (d.StartDate != nil || d.EndDate != nil):
+		return start, end, errors.New("Relevant date must have either date or start and end dates")
+	case d.Date != nil:
+		start = time.Time(*d.Date)
+		return start, start, nil
+	case d.StartDate == nil || d.EndDate == nil:
+		return start, end, errors.New("Relevant date must have both start and end dates")
+	}
+	start, end = time.Time(*d.StartDate), time.Time(*d.EndDate)
+	if !start.Before(end) {
+		return start, end, errors.New("Relevant start date must be before end date")
+	}
+	return start, end, nil
+}
+
+// validateRelevantDates checks that the relevant dates are in chronological
+// order and the intervals don't overlap.
+func validateRelevantDates(dates []RelevantDate) error {
+	var prevEnd time.Time
+	for i, date := range dates {
+		start, end, err := date.interval()
+		if err != nil {
+			return fmt.Errorf("%v (relevantDates[%d])", err, i)
+		}
+		if i > 0 && start.Before(prevEnd) {
+			return fmt.Errorf("Relevant dates must be in chronological order without overlaps (relevantDates[%d])", i)
+		}
+		prevEnd = end
+	}
+	return nil
+}
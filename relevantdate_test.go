@@ -0,0 +1,67 @@
+package passbook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelevantDates(t *testing.T) {
+	date := func(s string) *W3Time {
+		ti, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w3 := W3Time(ti)
+		return &w3
+	}
+	day1 := RelevantDate{StartDate: date("2031-07-10T10:00:00+02:00"), EndDate: date("2031-07-10T23:00:00+02:00")}
+	day2 := RelevantDate{StartDate: date("2031-07-11T10:00:00+02:00"), EndDate: date("2031-07-11T23:00:00+02:00")}
+	for _, test := range []struct {
+		dates []RelevantDate
+		err   string
+	}{
+		{nil, ""},
+		{[]RelevantDate{day1, day2}, ""},
+		{[]RelevantDate{day1, {Date: date("2031-07-11T09:00:00+02:00")}, day2}, ""},
+		{[]RelevantDate{day2, day1}, "chronological order"},
+		{[]RelevantDate{day1, {Date: date("2031-07-10T12:00:00+02:00")}}, "chronological order"},
+		{[]RelevantDate{day1, {StartDate: day1.EndDate, EndDate: day2.EndDate}}, ""},
+		{[]RelevantDate{{StartDate: day1.StartDate, EndDate: day2.EndDate}, day2}, "overlaps"},
+		{[]RelevantDate{{StartDate: day1.EndDate, EndDate: day1.StartDate}}, "before end date"},
+		{[]RelevantDate{{StartDate: day1.StartDate}}, "both start and end"},
+		{[]RelevantDate{{Date: day1.StartDate, EndDate: day1.EndDate}}, "either date"},
+	} {
+		err := validateRelevantDates(test.dates)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("unexpected error: %v", err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("error %q expected, got %v", test.err, err)
+		}
+	}
+	// the first date is written as relevantDate for older versions
+	pass := Pass{
+		Description:        "Festival",
+		OrganizationName:   "Example Inc.",
+		PassTypeIdentifier: "pass.com.example.festival",
+		SerialNumber:       "1",
+		TeamIdentifier:     "A93A5CM278",
+		EventTicket:        new(Fields),
+		RelevantDates:      []RelevantDate{day1, day2},
+	}
+	data, err := pass.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		RelevantDate string `json:"relevantDate"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.RelevantDate != "2031-07-10T10:00+02:00" {
+		t.Errorf("relevantDate: %q", result.RelevantDate)
+	}
+}
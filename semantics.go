@@ -0,0 +1,80 @@
+package passbook
+
+// SemanticTags Dictionary: Machine-readable metadata that the system uses to
+// offer a pass and suggest related actions. Available in iOS 12.0.
+// Only the commonly used tags are defined; other tags are kept in Extensions.
+type SemanticTags struct {
+	// Event Tags: Information about an event.
+	EventName      string         `json:"eventName,omitempty"`      // Full name of the event, such as the title of a movie.
+	EventType      string         `json:"eventType,omitempty"`      // Type of event, such as PKEventTypeSports.
+	EventStartDate *W3Time        `json:"eventStartDate,omitempty"` // Date and time the event starts.
+	EventEndDate   *W3Time        `json:"eventEndDate,omitempty"`   // Date and time the event ends.
+	PerformerNames []string       `json:"performerNames,omitempty"` // Full names of the performers and opening acts at the event.
+	VenueName      string         `json:"venueName,omitempty"`      // Full name of the venue.
+	VenueRoom      string         `json:"venueRoom,omitempty"`      // Full name of the room where the event takes place.
+	VenueEntrance  string         `json:"venueEntrance,omitempty"`  // Full name of the entrance, such as Gate A, to use to gain access to the ticketed event.
+	VenueLocation  *SemanticPlace `json:"venueLocation,omitempty"`  // Geographic coordinates of the venue.
+	Seats          []SemanticSeat `json:"seats,omitempty"`          // Seating details for all seats at the event or transit journey.
+	// Transit Tags: Information about a transit journey.
+	TransitProvider                string              `json:"transitProvider,omitempty"`                // Name of the transit company.
+	VehicleNumber                  string              `json:"vehicleNumber,omitempty"`                  // Identifier of the vehicle to board, such as the train number.
+	FlightCode                     string              `json:"flightCode,omitempty"`                     // IATA flight code, such as EX123.
+	AirlineCode                    string              `json:"airlineCode,omitempty"`                    // IATA airline code, such as EX.
+	ConfirmationNumber             string              `json:"confirmationNumber,omitempty"`             // Booking or reservation confirmation number.
+	BoardingGroup                  string              `json:"boardingGroup,omitempty"`                  // Group number for boarding.
+	BoardingSequenceNumber         string              `json:"boardingSequenceNumber,omitempty"`         // Sequence number for boarding.
+	PassengerName                  *SemanticPersonName `json:"passengerName,omitempty"`                  // Name of the passenger.
+	DepartureAirportCode           string              `json:"departureAirportCode,omitempty"`           // IATA airport code for the departure airport, such as SFO.
+	DepartureGate                  string              `json:"departureGate,omitempty"`                  // Gate number or letters of the departure gate, such as 1A.
+	DepartureTerminal              string              `json:"departureTerminal,omitempty"`              // Name or letter of the departure terminal, such as A.
+	DepartureStationName           string              `json:"departureStationName,omitempty"`           // Name of the departure station.
+	DepartureLocationDescription   string              `json:"departureLocationDescription,omitempty"`   // Brief description of the departure location.
+	DepartureLocation              *SemanticPlace      `json:"departureLocation,omitempty"`              // Geographic coordinates of the departure location.
+	DestinationAirportCode         string              `json:"destinationAirportCode,omitempty"`         // IATA airport code for the destination airport.
+	DestinationGate                string              `json:"destinationGate,omitempty"`                // Gate number or letters of the destination gate.
+	DestinationTerminal            string              `json:"destinationTerminal,omitempty"`            // Name or letter of the destination terminal.
+	DestinationStationName         string              `json:"destinationStationName,omitempty"`         // Name of the destination station.
+	DestinationLocationDescription string              `json:"destinationLocationDescription,omitempty"` // Brief description of the destination location.
+	DestinationLocation            *SemanticPlace      `json:"destinationLocation,omitempty"`            // Geographic coordinates of the destination location.
+	OriginalDepartureDate          *W3Time             `json:"originalDepartureDate,omitempty"`          // Originally scheduled date and time of departure.
+	CurrentDepartureDate           *W3Time             `json:"currentDepartureDate,omitempty"`           // Updated date and time of departure, if different from the original.
+	OriginalArrivalDate            *W3Time             `json:"originalArrivalDate,omitempty"`            // Originally scheduled date and time of arrival.
+	CurrentArrivalDate             *W3Time             `json:"currentArrivalDate,omitempty"`             // Updated date and time of arrival, if different from the original.
+	// Store Card Tags: Information about a store card or a purchase.
+	Balance    *SemanticAmount `json:"balance,omitempty"`    // Current balance redeemable with the pass.
+	TotalPrice *SemanticAmount `json:"totalPrice,omitempty"` // Total price for the pass.
+	// Unrecognized tags, such as tags of newer versions, preserved as is.
+	Extensions Extensions `json:"-"`
+}
+
+// SemanticAmount Dictionary: An object that represents an amount of money and type of currency.
+type SemanticAmount struct {
+	Amount       string `json:"amount"`                 // Amount of money.
+	CurrencyCode string `json:"currencyCode,omitempty"` // ISO 4217 currency code for the amount.
+}
+
+// SemanticPlace Dictionary: An object that represents the coordinates of a location.
+type SemanticPlace struct {
+	Latitude  float64 `json:"latitude"`  // Latitude, in degrees.
+	Longitude float64 `json:"longitude"` // Longitude, in degrees.
+}
+
+// SemanticPersonName Dictionary: An object that represents the parts of a person’s name.
+type SemanticPersonName struct {
+	GivenName  string `json:"givenName,omitempty"`  // Given name; also known as first name.
+	MiddleName string `json:"middleName,omitempty"` // Secondary name bestowed upon a person by the parents.
+	FamilyName string `json:"familyName,omitempty"` // Family name; also known as last name.
+	NamePrefix string `json:"namePrefix,omitempty"` // Prefix for the person’s name, such as “Dr.”
+	NameSuffix string `json:"nameSuffix,omitempty"` // Suffix for the person’s name, such as “Junior.”
+	Nickname   string `json:"nickname,omitempty"`   // Nickname of the person.
+}
+
+// SemanticSeat Dictionary: An object that represents the identification of a seat for a transit journey or an event.
+type SemanticSeat struct {
+	SeatDescription string `json:"seatDescription,omitempty"` // Description of the seat, such as A flat bed seat.
+	SeatIdentifier  string `json:"seatIdentifier,omitempty"`  // Identifier code for the seat.
+	SeatNumber      string `json:"seatNumber,omitempty"`      // Number of the seat.
+	SeatRow         string `json:"seatRow,omitempty"`         // Identifier code for the row of the seat.
+	SeatSection     string `json:"seatSection,omitempty"`     // Section that contains the seat.
+	SeatType        string `json:"seatType,omitempty"`        // Type of seat, such as Reserved seating.
+}
@@ -0,0 +1,65 @@
+package passbook
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"github.com/mdigger/pkcs7sign"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Signer creates a detached signature of the pass manifest.
+type Signer interface {
+	Sign(manifest []byte) ([]byte, error)
+}
+
+// Identity is a signing identity: the pass type certificate issued by Apple
+// and the private key that belongs to it.
+type Identity struct {
+	Certificate *x509.Certificate // Certificate used for signature
+	PrivateKey  *rsa.PrivateKey   // Private key used for signature
+	SigningTime time.Time         // Time of signing; if zero, the current time is used
+}
+
+// NewIdentity returns the signing identity for the certificate and the private
+// key. ErrKeyMismatch is returned if the key does not belong to the certificate.
+func NewIdentity(cert *x509.Certificate, priv *rsa.PrivateKey) (*Identity, error) {
+	if !priv.PublicKey.Equal(cert.PublicKey) {
+		return nil, ErrKeyMismatch
+	}
+	return &Identity{Certificate: cert, PrivateKey: priv}, nil
+}
+
+// Sign returns a PKCS #7 detached signature of the manifest.
+func (id *Identity) Sign(manifest []byte) ([]byte, error) {
+	signature, err := pkcs7.Sign(bytes.NewReader(manifest), id.Certificate, id.PrivateKey)
+	if err != nil || id.SigningTime.IsZero() {
+		return signature, err
+	}
+	return setSigningTime(signature, id.PrivateKey, id.SigningTime)
+}
+
+// SigningCertificate returns the certificate of the identity.
+func (id *Identity) SigningCertificate() *x509.Certificate {
+	return id.Certificate
+}
+
+// MarshalPEM returns the certificate and the unencrypted private key of the
+// identity in PEM format.
+func (id *Identity) MarshalPEM() []byte {
+	var buf bytes.Buffer
+	pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: id.Certificate.Raw})
+	pem.Encode(&buf, &pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(id.PrivateKey),
+	})
+	return buf.Bytes()
+}
+
+// MarshalPKCS12 returns the identity in PKCS #12 format protected with the password.
+func (id *Identity) MarshalPKCS12(password string) ([]byte, error) {
+	return pkcs12.Modern.Encode(id.PrivateKey, id.Certificate, nil, password)
+}
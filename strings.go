@@ -0,0 +1,211 @@
+package passbook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalidStrings is wrapped by the errors of parsing .strings files.
+var ErrInvalidStrings = errors.New("invalid .strings file")
+
+// encodeStrings returns the localized strings in the format of .strings file.
+func (l Localization) encodeStrings() []byte {
+	var buf bytes.Buffer
+	for _, key := range sortedKeys(l.Strings) {
+		fmt.Fprintf(&buf, "\"%s\" = \"%s\";\n", escapeString(key), escapeString(l.Strings[key]))
+	}
+	return buf.Bytes()
+}
+
+var stringsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// escapeString escapes the string for use in .strings file.
+func escapeString(s string) string {
+	return stringsEscaper.Replace(s)
+}
+
+// ParseStrings parses the content of .strings file with localized strings.
+// The file can be encoded in UTF-8 or UTF-16 with byte order mark.
+func ParseStrings(data []byte) (map[string]string, error) {
+	text, err := decodeStrings(data)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	p := &stringsParser{text: text}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return result, nil
+		}
+		key, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("expected =")
+		}
+		p.skipSpace()
+		value, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(';') {
+			return nil, p.errorf("expected ;")
+		}
+		result[key] = value
+	}
+}
+
+// decodeStrings returns the text of .strings file.
+func decodeStrings(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("%w: odd length of UTF-16 data", ErrInvalidStrings)
+		}
+		bigEndian := data[0] == 0xFE
+		units := make([]uint16, 0, len(data)/2-1)
+		for i := 2; i < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+			} else {
+				units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+			}
+		}
+		return string(utf16.Decode(units)), nil
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: invalid UTF-8 data", ErrInvalidStrings)
+	}
+	return string(data), nil
+}
+
+// stringsParser parses the text of .strings file.
+type stringsParser struct {
+	text string
+	pos  int
+}
+
+func (p *stringsParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.text[:p.pos], "\n") + 1
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidStrings, line, fmt.Sprintf(format, args...))
+}
+
+// consume skips the character c, if it is the next one.
+func (p *stringsParser) consume(c byte) bool {
+	if p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips white space and comments.
+func (p *stringsParser) skipSpace() {
+	for p.pos < len(p.text) {
+		switch rest := p.text[p.pos:]; {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n':
+			p.pos++
+		case strings.HasPrefix(rest, "//"):
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				p.pos += i + 1
+			} else {
+				p.pos = len(p.text)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if i := strings.Index(rest[2:], "*/"); i >= 0 {
+				p.pos += i + 4
+			} else {
+				p.pos = len(p.text)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the quoted string or the unquoted word.
+func (p *stringsParser) token() (string, error) {
+	if !p.consume('"') {
+		start := p.pos
+		for p.pos < len(p.text) && strings.IndexByte(" \t\r\n=;\"", p.text[p.pos]) < 0 {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", p.errorf("expected string")
+		}
+		return p.text[start:p.pos], nil
+	}
+	var buf strings.Builder
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return buf.String(), nil
+		case '\\':
+			if p.pos >= len(p.text) {
+				return "", p.errorf("unterminated string")
+			}
+			c = p.text[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'U', 'u':
+				r, err := p.unicode()
+				if err != nil {
+					return "", err
+				}
+				// characters outside of BMP are escaped as UTF-16 surrogate pairs
+				if utf16.IsSurrogate(r) {
+					if !strings.HasPrefix(p.text[p.pos:], `\U`) && !strings.HasPrefix(p.text[p.pos:], `\u`) {
+						return "", p.errorf("unpaired surrogate in unicode escape")
+					}
+					p.pos += 2
+					low, err := p.unicode()
+					if err != nil {
+						return "", err
+					}
+					if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+						return "", p.errorf("unpaired surrogate in unicode escape")
+					}
+				}
+				buf.WriteRune(r)
+			default:
+				buf.WriteByte(c)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// unicode returns the character of the unicode escape with four hexadecimal
+// digits, which follow \U.
+func (p *stringsParser) unicode() (rune, error) {
+	if p.pos+4 > len(p.text) {
+		return 0, p.errorf("bad unicode escape")
+	}
+	code, err := strconv.ParseUint(p.text[p.pos:p.pos+4], 16, 16)
+	if err != nil {
+		return 0, p.errorf("bad unicode escape")
+	}
+	p.pos += 4
+	return rune(code), nil
+}
@@ -0,0 +1,85 @@
+package passbook
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseStrings(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data string
+		want map[string]string
+		err  string
+	}{
+		{"empty", "", map[string]string{}, ""},
+		{"quoted", `"key" = "value";`, map[string]string{"key": "value"}, ""},
+		{"unquoted", `key=value;`, map[string]string{"key": "value"}, ""},
+		{"spaces", " \"a b\"\t=\r\n\"c d\" ;\n", map[string]string{"a b": "c d"}, ""},
+		{"comments", "/* header\n comment */\n\"a\" = \"1\"; // line comment\n// \"b\" = \"2\";\n\"c\" /* inline */ = \"3\";",
+			map[string]string{"a": "1", "c": "3"}, ""},
+		{"escapes", `"k" = "a\"b\\c\nd\re\tf\'g";`, map[string]string{"k": "a\"b\\c\nd\re\tf'g"}, ""},
+		{"unicode", `"k" = "\U00e9€";`, map[string]string{"k": "é€"}, ""},
+		{"surrogate pair", `"k" = "\UD83D\UDE00!";`, map[string]string{"k": "😀!"}, ""},
+		{"duplicate", `"k" = "1"; "k" = "2";`, map[string]string{"k": "2"}, ""},
+		{"no equal sign", `"k" "v";`, nil, "line 1: expected ="},
+		{"no semicolon", "\"k\" = \"v\"\n\"l\" = \"w\";", nil, "line 2: expected ;"},
+		{"no value", `"k" = ;`, nil, "expected string"},
+		{"unterminated", `"k" = "v;`, nil, "unterminated string"},
+		{"unterminated escape", `"k" = "v\`, nil, "unterminated string"},
+		{"short unicode", `"k" = "\U12";`, nil, "bad unicode escape"},
+		{"bad unicode", `"k" = "\U12zz";`, nil, "bad unicode escape"},
+		{"unpaired surrogate", `"k" = "\UD83D!";`, nil, "unpaired surrogate"},
+		{"two high surrogates", `"k" = "\UD83D\UD83D";`, nil, "unpaired surrogate"},
+		{"low surrogate", `"k" = "\UDE00\U0041";`, nil, "unpaired surrogate"},
+	} {
+		got, err := ParseStrings([]byte(test.data))
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: %v, want %q", test.name, err, test.err)
+			}
+			if !errors.Is(err, ErrInvalidStrings) {
+				t.Errorf("%s: %v is not ErrInvalidStrings", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseStringsEncoding(t *testing.T) {
+	const text = `"title" = "Билет 😀";`
+	want := map[string]string{"title": "Билет 😀"}
+	units := utf16.Encode([]rune(text))
+	littleEndian, bigEndian := []byte{0xFF, 0xFE}, []byte{0xFE, 0xFF}
+	for _, u := range units {
+		littleEndian = append(littleEndian, byte(u), byte(u>>8))
+		bigEndian = append(bigEndian, byte(u>>8), byte(u))
+	}
+	for name, data := range map[string][]byte{
+		"UTF-8":     []byte(text),
+		"UTF-8 BOM": append([]byte{0xEF, 0xBB, 0xBF}, text...),
+		"UTF-16LE":  littleEndian,
+		"UTF-16BE":  bigEndian,
+	} {
+		got, err := ParseStrings(data)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseStrings([]byte{0xFF, 0xFE, 0x41}); !errors.Is(err, ErrInvalidStrings) {
+		t.Error("odd length of UTF-16 data is accepted:", err)
+	}
+	if _, err := ParseStrings([]byte{'"', 0xC3, '"'}); !errors.Is(err, ErrInvalidStrings) {
+		t.Error("invalid UTF-8 data is accepted:", err)
+	}
+}
@@ -5,9 +5,19 @@ type BarcodeFormat string
 
 // Supported Barcode formats.
 const (
-	PKBarcodeFormatQR     BarcodeFormat = "PKBarcodeFormatQR"
-	PKBarcodeFormatPDF417               = "PKBarcodeFormatPDF417"
-	PKBarcodeFormatAztec                = "PKBarcodeFormatAztec"
+	PKBarcodeFormatQR      BarcodeFormat = "PKBarcodeFormatQR"
+	PKBarcodeFormatPDF417                = "PKBarcodeFormatPDF417"
+	PKBarcodeFormatAztec                 = "PKBarcodeFormatAztec"
+	PKBarcodeFormatCode128               = "PKBarcodeFormatCode128" // Only in the barcodes array. Available in iOS 9.0.
+)
+
+// Layout of the event ticket.
+type StyleScheme string
+
+// Supported layouts of the event ticket.
+const (
+	PKStyleSchemePosterEventTicket StyleScheme = "posterEventTicket" // Poster layout. Available in iOS 18.0.
+	PKStyleSchemeEventTicket                   = "eventTicket"       // Regular layout.
 )
 
 type DataDetector string
@@ -58,3 +68,14 @@ const (
 	PKNumberStyleScientific             = "PKNumberStyleScientific"
 	PKNumberStyleSpellOut               = "PKNumberStyleSpellOut"
 )
+
+// Field of personal information requested at reward program sign-up.
+type PersonalizationField string
+
+// Supported fields of personal information.
+const (
+	PKPassPersonalizationFieldName         PersonalizationField = "PKPassPersonalizationFieldName"
+	PKPassPersonalizationFieldPostalCode                        = "PKPassPersonalizationFieldPostalCode"
+	PKPassPersonalizationFieldEmailAddress                      = "PKPassPersonalizationFieldEmailAddress"
+	PKPassPersonalizationFieldPhoneNumber                       = "PKPassPersonalizationFieldPhoneNumber"
+)
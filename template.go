@@ -0,0 +1,191 @@
+package passbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"text/template"
+)
+
+// Template describes a pass directory, where pass.json, personalization.json
+// and localized .strings files are text/template templates. The templates are parsed once and can be
+// executed with the data of every pass holder, also concurrently.
+//
+// Besides the standard functions, the templates can use json, which returns
+// the value encoded as JSON, and strings, which escapes the value for use
+// inside a quoted string of .strings file:
+//
+//	"serialNumber": {{json .Serial}},
+//	"seat" = "{{strings .Seat}}";
+type Template struct {
+	tmpl  *template.Template // Parsed templates by file name
+	files map[string][]byte  // Content of static files, such as images
+	names []string           // Names of all files in the order of adding
+}
+
+// templateFuncs are the additional functions available in templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"strings": escapeString,
+}
+
+// ParseTemplateDir parses the pass template from the directory.
+func ParseTemplateDir(dir string) (*Template, error) {
+	return ParseTemplateFS(os.DirFS(dir))
+}
+
+// ParseTemplateFS parses the pass template from the file system. Files that
+// can't be a part of Passbook are ignored.
+func ParseTemplateFS(fsys fs.FS) (*Template, error) {
+	t := &Template{
+		tmpl:  template.New("").Funcs(templateFuncs).Option("missingkey=error"),
+		files: make(map[string][]byte),
+	}
+	if err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch path.Ext(name) {
+		case ".json": // from json-files we take only the description and personalization
+			if name != "pass.json" && name != "personalization.json" {
+				return nil
+			}
+		case ".png", ".strings":
+		default:
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if path.Ext(name) == ".png" {
+			t.files[name] = data
+		} else if _, err = t.tmpl.New(name).Parse(string(data)); err != nil {
+			return err
+		}
+		t.names = append(t.names, name)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if t.tmpl.Lookup("pass.json") == nil {
+		return nil, ErrNoPass
+	}
+	return t, nil
+}
+
+// Pass executes the pass.json template with data and returns the checked
+// description of the pass.
+func (t *Template) Pass(data interface{}) (*Pass, error) {
+	return t.SignerPass(nil, data)
+}
+
+// SignerPass is like Pass, but if the signer is CertificateSigner, the empty
+// identifiers of the pass are filled from its certificate, as Execute does.
+func (t *Template) SignerPass(signer Signer, data interface{}) (*Pass, error) {
+	prepared, err := t.Prepare(signer, data)
+	if err != nil {
+		return nil, err
+	}
+	return prepared.Pass, nil
+}
+
+// PreparedPass is the pass.json template executed with the data of the pass
+// holder. It allows to check the pass, e.g. its serial number, before the
+// Passbook file is written, without executing the template twice.
+type PreparedPass struct {
+	Pass     *Pass // Checked description of the pass
+	t        *Template
+	signer   Signer
+	data     interface{}
+	passData []byte // Rendered pass.json
+}
+
+// Prepare executes the pass.json template with data and checks the pass, as
+// SignerPass does. The other templates are executed by Write.
+func (t *Template) Prepare(signer Signer, data interface{}) (*PreparedPass, error) {
+	passData, err := t.renderPass(signer, data)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := parsePass(passData)
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedPass{Pass: pass, t: t, signer: signer, data: data, passData: passData}, nil
+}
+
+// Execute executes the templates with data and writes the signed Passbook file to w.
+// If the signer is CertificateSigner, the empty identifiers of the pass are
+// filled from its certificate.
+func (t *Template) Execute(w io.Writer, signer Signer, data interface{}) error {
+	prepared, err := t.Prepare(signer, data)
+	if err != nil {
+		return err
+	}
+	return prepared.Write(w)
+}
+
+// Write executes the other templates and writes the signed Passbook file with
+// the prepared pass.json to w. If a file can't be written, the archive is
+// finished without the manifest and the signature.
+func (p *PreparedPass) Write(w io.Writer) error {
+	pw := NewSignerWriter(w, p.signer)
+	for _, name := range p.t.names {
+		content, ok := p.t.files[name]
+		if !ok {
+			var err error
+			if name == "pass.json" {
+				content = p.passData
+			} else if content, err = p.t.render(name, p.data); err != nil {
+				pw.abort(err)
+				return err
+			}
+		}
+		if err := pw.Add(name, bytes.NewReader(content)); err != nil {
+			pw.abort(err)
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// renderPass executes the pass.json template with data and fills the
+// identifiers of the pass from the certificate of the signer.
+func (t *Template) renderPass(signer Signer, data interface{}) ([]byte, error) {
+	passData, err := t.render("pass.json", data)
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := signer.(CertificateSigner); ok {
+		return fillPassIdentifiers(passData, signer.SigningCertificate())
+	}
+	return passData, nil
+}
+
+// render executes the named template with data.
+func (t *Template) render(name string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parsePass parses and validates the description of the pass.
+func parsePass(data []byte) (*Pass, error) {
+	pass := new(Pass)
+	if err := json.Unmarshal(data, pass); err != nil {
+		return nil, err
+	}
+	if err := pass.Validate(); err != nil {
+		return nil, err
+	}
+	return pass, nil
+}
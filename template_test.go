@@ -0,0 +1,212 @@
+package passbook
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testTemplate is the pass directory with templates. The pass has no web
+// service, so it has no authentication token.
+var testTemplate = fstest.MapFS{
+	"pass.json": {Data: []byte(`{
+	"formatVersion": 1,
+	"passTypeIdentifier": "pass.com.example.test",
+	"serialNumber": {{json .Serial}},
+	"teamIdentifier": "A93A5CM278",
+	"organizationName": "Example Inc.",
+	"description": "Concert ticket",
+	"eventTicket": {"primaryFields": [{"key": "name", "value": {{json .Name}}}]}
+}`)},
+	"en.lproj/pass.strings": {Data: []byte(`"seat" = "{{strings .Seat}}";`)},
+	"icon.png":              {Data: []byte("icon")},
+	"readme.txt":            {Data: []byte("ignored")},
+	"template.json":         {Data: []byte("ignored")},
+}
+
+// testHolder is the data of the pass holder for testTemplate.
+var testHolder = map[string]string{"Serial": "42", "Name": `John "Johnny" Appleseed`, "Seat": "A\"12\n"}
+
+func TestParseTemplateFS(t *testing.T) {
+	if _, err := ParseTemplateFS(fstest.MapFS{"icon.png": {Data: []byte("icon")}}); err != ErrNoPass {
+		t.Errorf("template without pass.json: %v", err)
+	}
+	if _, err := ParseTemplateFS(fstest.MapFS{"pass.json": {Data: []byte(`{"serialNumber": {{.Serial}`)}}); err == nil {
+		t.Error("bad template is parsed")
+	}
+	tmpl, err := ParseTemplateFS(testTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"en.lproj/pass.strings", "icon.png", "pass.json"}
+	if strings.Join(tmpl.names, ",") != strings.Join(want, ",") {
+		t.Errorf("files %q, want %q", tmpl.names, want)
+	}
+}
+
+func TestTemplatePass(t *testing.T) {
+	tmpl, err := ParseTemplateFS(testTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass, err := tmpl.Pass(testHolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass.SerialNumber != "42" || pass.EventTicket == nil ||
+		pass.EventTicket.Primary[0].Value != testHolder["Name"] {
+		t.Errorf("unexpected pass: %+v", pass)
+	}
+	// the missing data is an error
+	if _, err := tmpl.Pass(map[string]string{"Name": "John"}); err == nil {
+		t.Error("missing key is ignored")
+	}
+}
+
+func TestTemplateWebService(t *testing.T) {
+	// the web service of the holder is optional
+	tmpl, err := ParseTemplateFS(fstest.MapFS{"pass.json": {Data: []byte(`{
+	"formatVersion": 1,
+	"passTypeIdentifier": "pass.com.example.test",
+	"serialNumber": "1",
+	"teamIdentifier": "A93A5CM278",
+	"organizationName": "Example Inc.",
+	"description": "Coupon",
+	{{if .Token}}"webServiceURL": "https://example.com/passes/",
+	"authenticationToken": {{json .Token}},{{end}}
+	"coupon": {}
+}`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		token string
+		err   string
+	}{
+		{"", ""},
+		{"vxwxd7J8AlNNFPS8k0a0FfUFtq0ewzFdc", ""},
+		{"short", "16 characters"},
+	} {
+		pass, err := tmpl.Pass(map[string]string{"Token": test.token})
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("token %q: %v", test.token, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("token %q: error %q expected, got %v", test.token, test.err, err)
+		case err == nil && pass.AuthenticationToken != test.token:
+			t.Errorf("token %q: rendered %q", test.token, pass.AuthenticationToken)
+		}
+	}
+}
+
+func TestTemplateExecute(t *testing.T) {
+	tmpl, err := ParseTemplateFS(testTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, testSigner{}, testHolder); err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, buf.Bytes())
+	want := []string{"en.lproj/pass.strings", "icon.png", "pass.json", "manifest.json", "signature"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("files %q, want %q", names, want)
+	}
+	localized, err := ParseStrings(files["en.lproj/pass.strings"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localized["seat"] != testHolder["Seat"] {
+		t.Errorf("seat %q, want %q", localized["seat"], testHolder["Seat"])
+	}
+	if !bytes.Contains(files["pass.json"], []byte(`"serialNumber": "42"`)) {
+		t.Errorf("unexpected pass.json: %s", files["pass.json"])
+	}
+	// the rendered description is validated
+	err = tmpl.Execute(&buf, testSigner{}, map[string]string{"Serial": "", "Name": "", "Seat": ""})
+	if err == nil || !strings.Contains(err.Error(), "Serial Number") {
+		t.Errorf("invalid pass is written: %v", err)
+	}
+	signErr := errors.New("sign error")
+	if err := tmpl.Execute(&buf, errorSigner{signErr}, testHolder); err != signErr {
+		t.Errorf("signer error %v, want %v", err, signErr)
+	}
+}
+
+// countingHolder is the data of the pass holder, which counts the executions
+// of the pass.json template.
+type countingHolder struct {
+	Name       string
+	executions int
+}
+
+func (h *countingHolder) Serial() string {
+	h.executions++
+	return "42"
+}
+
+func TestTemplatePrepare(t *testing.T) {
+	tmpl, err := ParseTemplateFS(fstest.MapFS{
+		"pass.json": testTemplate["pass.json"],
+		"icon.png":  testTemplate["icon.png"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder := &countingHolder{Name: "John"}
+	prepared, err := tmpl.Prepare(testSigner{}, holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared.Pass.SerialNumber != "42" {
+		t.Errorf("serial number %q", prepared.Pass.SerialNumber)
+	}
+	var buf bytes.Buffer
+	if err := prepared.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if holder.executions != 1 {
+		t.Errorf("pass.json is executed %d times", holder.executions)
+	}
+	_, files := readZip(t, buf.Bytes())
+	if !bytes.Contains(files["pass.json"], []byte(`"serialNumber": "42"`)) {
+		t.Errorf("unexpected pass.json: %s", files["pass.json"])
+	}
+}
+
+// errorSigner always returns the error.
+type errorSigner struct{ err error }
+
+func (s errorSigner) Sign([]byte) ([]byte, error) {
+	return nil, s.err
+}
+
+func TestTemplateSignerPass(t *testing.T) {
+	tmpl, err := ParseTemplateFS(fstest.MapFS{"pass.json": {Data: []byte(`{
+	"serialNumber": {{json .Serial}},
+	"organizationName": "Example Inc.",
+	"description": "Store card",
+	"storeCard": {}
+}`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]string{"Serial": "7"}
+	// the identifiers are required without the certificate
+	if _, err := tmpl.Pass(data); err == nil || !strings.Contains(err.Error(), "Pass Type Identifier") {
+		t.Errorf("invalid pass is returned: %v", err)
+	}
+	pass, err := tmpl.SignerPass(testIdentity(t, true), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass.PassTypeIdentifier != "pass.com.example.test" || pass.TeamIdentifier != "A93A5CM278" {
+		t.Errorf("identifiers are not filled: %q, %q", pass.PassTypeIdentifier, pass.TeamIdentifier)
+	}
+	if _, err := tmpl.SignerPass(testIdentity(t, false), data); !errors.Is(err, ErrNotPassCertificate) {
+		t.Errorf("certificate without extension: %v", err)
+	}
+}
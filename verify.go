@@ -0,0 +1,224 @@
+package passbook
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Severity of the problem found in the Passbook.
+type Severity int
+
+// Severities of the problems.
+const (
+	SeverityWarning Severity = iota // The pass works, but something should be fixed
+	SeverityError                   // The pass is invalid and will be rejected by Wallet
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Problem describes the problem found in the Passbook.
+type Problem struct {
+	Severity Severity // Severity of the problem
+	Message  string   // Description of the problem
+}
+
+func (p Problem) String() string {
+	return p.Severity.String() + ": " + p.Message
+}
+
+// VerifyOptions are the options of Reader.Verify.
+type VerifyOptions struct {
+	Roots         *x509.CertPool // Trusted root certificates, such as Apple Root CA; if nil, the chain is not checked
+	Intermediates *x509.CertPool // Intermediate certificates, such as Apple WWDR, in addition to those in the signature
+	CurrentTime   time.Time      // Time to check the expiry of the certificate; if zero, the current time is used
+}
+
+// expiryWarning is the time before the expiry of the certificate when it is reported.
+const expiryWarning = 30 * 24 * time.Hour
+
+// imageStyles lists the pass styles that support the image; nil means all styles.
+var imageStyles = map[string][]string{
+	"icon":                nil,
+	"logo":                nil,
+	"personalizationLogo": {"storeCard"},
+	"strip":               {"coupon", "eventTicket", "storeCard"},
+	"background":          {"eventTicket"},
+	"thumbnail":           {"eventTicket", "generic"},
+	"footer":              {"boardingPass"},
+	"primaryLogo":         {"eventTicket"},
+	"secondaryLogo":       {"eventTicket"},
+}
+
+// Verify checks the Passbook: the pass description, hashes of the files in
+// the manifest, the signature and its certificate, and the images. It returns
+// the list of found problems.
+func (r *Reader) Verify(opts VerifyOptions) []Problem {
+	var problems []Problem
+	report := func(severity Severity, format string, args ...interface{}) {
+		problems = append(problems, Problem{severity, fmt.Sprintf(format, args...)})
+	}
+	if err := r.Pass.Validate(); err != nil {
+		report(SeverityError, "pass.json: %v", err)
+	}
+	for _, warning := range r.Pass.Warnings() {
+		report(SeverityWarning, "pass.json: %s", warning)
+	}
+	// hashes of the files
+	if r.Manifest == nil {
+		report(SeverityError, "manifest.json missed")
+	} else {
+		for _, name := range r.Names() {
+			if name == "manifest.json" || name == "signature" {
+				continue
+			}
+			hash := sha1.Sum(r.Files[name])
+			switch manifestHash, ok := r.Manifest[name]; {
+			case !ok:
+				report(SeverityError, "%s is not listed in manifest", name)
+			case !strings.EqualFold(manifestHash, hex.EncodeToString(hash[:])):
+				report(SeverityError, "%s does not match hash in manifest", name)
+			}
+		}
+		for _, name := range sortedKeys(r.Manifest) {
+			if _, ok := r.Files[name]; !ok {
+				report(SeverityError, "%s is listed in manifest, but missed", name)
+			}
+		}
+	}
+	// signature and certificate
+	problems = append(problems, r.verifySignature(opts)...)
+	// images
+	style, _ := r.Pass.Style()
+	if _, ok := r.Files["icon.png"]; !ok {
+		report(SeverityError, "icon.png missed")
+	}
+	for _, name := range []string{"icon@2x.png", "logo.png"} {
+		if _, ok := r.Files[name]; !ok {
+			report(SeverityWarning, "%s missed", name)
+		}
+	}
+	var hasStrip, hasBackground bool
+	for _, name := range r.Names() {
+		if path.Ext(name) != ".png" {
+			continue
+		}
+		image := imageName(name)
+		styles, ok := imageStyles[image]
+		if !ok {
+			report(SeverityWarning, "%s is not used by Wallet", name)
+			continue
+		}
+		if styles != nil && style != "" && !contains(styles, style) {
+			report(SeverityWarning, "%s is not used by %s passes", name, style)
+		}
+		hasStrip = hasStrip || image == "strip"
+		hasBackground = hasBackground || image == "background" || image == "thumbnail"
+	}
+	if style == "eventTicket" && hasStrip && hasBackground {
+		report(SeverityWarning, "strip image can't be used with background or thumbnail image")
+	}
+	return problems
+}
+
+// verifySignature checks the signature of the manifest and the certificate of the signer.
+func (r *Reader) verifySignature(opts VerifyOptions) []Problem {
+	var problems []Problem
+	report := func(severity Severity, format string, args ...interface{}) {
+		problems = append(problems, Problem{severity, fmt.Sprintf(format, args...)})
+	}
+	if r.Signature == nil {
+		report(SeverityError, "signature missed")
+		return problems
+	}
+	sd, err := parseSignature(r.Signature)
+	if err != nil {
+		report(SeverityError, "bad signature: %v", err)
+		return problems
+	}
+	if manifestData, ok := r.Files["manifest.json"]; ok {
+		if err := sd.verify(manifestData); err != nil {
+			report(SeverityError, "signature of manifest is invalid: %v", err)
+		}
+	}
+	cert, err := sd.signer()
+	if err != nil {
+		report(SeverityError, "bad signature: %v", err)
+		return problems
+	}
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	switch {
+	case now.After(cert.NotAfter):
+		report(SeverityError, "certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+	case now.Add(expiryWarning).After(cert.NotAfter):
+		report(SeverityWarning, "certificate expires at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	passTypeIdentifier, teamIdentifier, err := CertificateIdentifiers(cert)
+	if err != nil {
+		report(SeverityError, "%v", err)
+	}
+	if passTypeIdentifier != "" && passTypeIdentifier != r.Pass.PassTypeIdentifier {
+		report(SeverityError, "pass type identifier %q does not match certificate %q",
+			r.Pass.PassTypeIdentifier, passTypeIdentifier)
+	}
+	if teamIdentifier != "" && teamIdentifier != r.Pass.TeamIdentifier {
+		report(SeverityError, "team identifier %q does not match certificate %q",
+			r.Pass.TeamIdentifier, teamIdentifier)
+	}
+	if opts.Roots != nil {
+		intermediates := x509.NewCertPool()
+		if opts.Intermediates != nil {
+			intermediates = opts.Intermediates.Clone()
+		}
+		if certs, err := sd.certificates(); err == nil {
+			for _, c := range certs {
+				intermediates.AddCert(c)
+			}
+		}
+		// the chain must be valid at the time of signing
+		verifyTime := sd.signingTime()
+		if verifyTime.IsZero() {
+			verifyTime = now
+		}
+		if _, err := cert.Verify(x509.VerifyOptions{
+			Roots:         opts.Roots,
+			Intermediates: intermediates,
+			CurrentTime:   verifyTime,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			report(SeverityError, "certificate is not trusted: %v", err)
+		}
+	}
+	return problems
+}
+
+// imageName returns the name of the image without directory, scale and extension.
+func imageName(name string) string {
+	name = strings.TrimSuffix(path.Base(name), ".png")
+	if i := strings.LastIndexByte(name, '@'); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// contains returns true if the list contains the string.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package passbook_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdigger/passbook"
+	"github.com/mdigger/passbook/passbooktest"
+)
+
+// testPackage returns the valid package without web service for tests of
+// verification.
+func testPackage() *passbook.Package {
+	return &passbook.Package{
+		Pass: passbook.Pass{
+			SerialNumber:     "1",
+			OrganizationName: "Example",
+			Description:      "Test pass",
+			Generic:          &passbook.Fields{},
+		},
+		Images: map[string][]byte{
+			"icon.png":    []byte("icon"),
+			"icon@2x.png": []byte("icon"),
+			"logo.png":    []byte("logo"),
+		},
+	}
+}
+
+// rezip returns the Passbook file with the files changed by edit.
+func rezip(t *testing.T, data []byte, edit func(files map[string][]byte)) []byte {
+	t.Helper()
+	reader := passbooktest.Open(t, data)
+	files := make(map[string][]byte)
+	for name, content := range reader.Files {
+		files[name] = content
+	}
+	if reader.Signature != nil {
+		files["signature"] = reader.Signature
+	}
+	edit(files)
+	var buf bytes.Buffer
+	zipw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zipw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zipw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReaderVerify(t *testing.T) {
+	identity, ca := passbooktest.NewIdentity(t, "pass.com.example.test", "A1B2C3D4E5")
+	valid := passbooktest.Build(t, testPackage(), identity)
+	webService := testPackage()
+	webService.Pass.WebServiceURL = "https://example.com/passes/"
+	webService.Pass.AuthenticationToken = "vxwxd7J8AlNNFPS8k0a0FfUFtq0ewzFdc"
+	now := time.Now()
+	expired, err := ca.NewIdentityValidity("pass.com.example.test", "A1B2C3D4E5",
+		now.AddDate(-1, 0, 0), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring, err := ca.NewIdentityValidity("pass.com.example.test", "A1B2C3D4E5",
+		now.Add(-time.Hour), now.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		severity passbook.Severity
+		message  string // empty if no problems are expected
+	}{
+		{"valid without web service", valid, 0, ""},
+		{"valid with web service", passbooktest.Build(t, webService, identity), 0, ""},
+		{"tampered", rezip(t, valid, func(files map[string][]byte) {
+			files["logo.png"] = []byte("other logo")
+		}), passbook.SeverityError, "logo.png does not match hash in manifest"},
+		{"not in manifest", rezip(t, valid, func(files map[string][]byte) {
+			files["strip.png"] = []byte("strip")
+		}), passbook.SeverityError, "strip.png is not listed in manifest"},
+		{"missing signature", rezip(t, valid, func(files map[string][]byte) {
+			delete(files, "signature")
+		}), passbook.SeverityError, "signature missed"},
+		{"corrupted signature", rezip(t, valid, func(files map[string][]byte) {
+			files["signature"] = files["signature"][:len(files["signature"])/2]
+		}), passbook.SeverityError, "bad signature"},
+		{"other manifest", rezip(t, valid, func(files map[string][]byte) {
+			files["manifest.json"] = bytes.Replace(files["manifest.json"], []byte("\t"), []byte(" "), 1)
+		}), passbook.SeverityError, "signature of manifest is invalid"},
+		{"expired", passbooktest.Build(t, testPackage(), expired),
+			passbook.SeverityError, "certificate expired"},
+		{"expiring", passbooktest.Build(t, testPackage(), expiring),
+			passbook.SeverityWarning, "certificate expires"},
+	} {
+		reader, err := passbook.NewReader(bytes.NewReader(test.data), int64(len(test.data)))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		problems := reader.Verify(ca.VerifyOptions())
+		if test.message == "" {
+			if len(problems) > 0 {
+				t.Errorf("%s: unexpected problems %v", test.name, problems)
+			}
+			continue
+		}
+		var found bool
+		for _, problem := range problems {
+			found = found || problem.Severity == test.severity &&
+				strings.Contains(problem.Message, test.message)
+		}
+		if !found {
+			t.Errorf("%s: %v %q not found in %v", test.name, test.severity, test.message, problems)
+		}
+	}
+}
@@ -2,18 +2,30 @@ package passbook
 
 import "time"
 
+// W3Time is the date and time in W3C format, such as 2012-07-22T14:25-08:00.
+// The time zone of the value is preserved.
 type W3Time time.Time
 
+// w3TimeFormat is the format of W3C date without seconds.
+const w3TimeFormat = "2006-01-02T15:04Z07:00"
+
+// UnmarshalJSON parses the date in W3C format without seconds or, as devices
+// do, in RFC 3339 format with seconds.
 func (t *W3Time) UnmarshalJSON(data []byte) error {
-	format := "\"2006-01-02T15:04-07:00\""
-	if ti, err := time.Parse(format, string(data)); err != nil {
-		return err
-	} else {
-		t = (*W3Time)(&ti)
+	ti, err := time.Parse("\""+w3TimeFormat+"\"", string(data))
+	if err != nil {
+		if ti, err = time.Parse("\""+time.RFC3339+"\"", string(data)); err != nil {
+			return err
+		}
 	}
+	*t = W3Time(ti)
 	return nil
 }
 
 func (t W3Time) MarshalJSON() ([]byte, error) {
-	return []byte(time.Time(t).UTC().Format("2006-01-02T15:04-07:00")), nil
+	format := time.RFC3339Nano
+	if ti := time.Time(t); ti.Second() == 0 && ti.Nanosecond() == 0 {
+		format = w3TimeFormat // seconds are omitted when not needed
+	}
+	return []byte(time.Time(t).Format("\"" + format + "\"")), nil
 }
@@ -0,0 +1,54 @@
+package passbook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestW3Time(t *testing.T) {
+	zone := time.FixedZone("", -8*60*60)
+	for _, test := range []struct {
+		data string
+		want time.Time
+		err  bool
+	}{
+		{`"2012-07-22T14:25-08:00"`, time.Date(2012, 7, 22, 14, 25, 0, 0, zone), false},
+		{`"2012-07-22T14:25Z"`, time.Date(2012, 7, 22, 14, 25, 0, 0, time.UTC), false},
+		{`"2012-07-22T14:25:30-08:00"`, time.Date(2012, 7, 22, 14, 25, 30, 0, zone), false},
+		{`"2012-07-22T14:25:30.5Z"`, time.Date(2012, 7, 22, 14, 25, 30, 5e8, time.UTC), false},
+		{`"2012-07-22"`, time.Time{}, true},
+		{`"22.07.2012 14:25"`, time.Time{}, true},
+		{`""`, time.Time{}, true},
+		{`1343000000`, time.Time{}, true},
+	} {
+		var w3 W3Time
+		err := json.Unmarshal([]byte(test.data), &w3)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: error expected, got %v", test.data, time.Time(w3))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.data, err)
+			continue
+		}
+		got := time.Time(w3)
+		if !got.Equal(test.want) {
+			t.Errorf("%s: %v, want %v", test.data, got, test.want)
+		}
+		_, offset := got.Zone()
+		if _, want := test.want.Zone(); offset != want {
+			t.Errorf("%s: time zone is not preserved: %v", test.data, got)
+		}
+		// the written date is read as the same time
+		data, err := json.Marshal(w3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.data {
+			t.Errorf("%s: written as %s", test.data, data)
+		}
+	}
+}
@@ -9,35 +9,83 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"path"
-
-	"github.com/mdigger/pkcs7sign"
+	"strings"
+	"time"
 )
 
 var ErrNoPass = errors.New("pass.json missed") // the description of pass.json was not added to the file
 
+// Errors of the file names added to the Passbook.
+var (
+	ErrDuplicateName = errors.New("duplicate file name")                 // file with the same name was already added
+	ErrCaseCollision = errors.New("file name differs only in case")      // file with the same name in other case was already added
+	ErrUnsafeName    = errors.New("unsafe file name")                    // absolute name or name with path traversal
+	ErrDirectory     = errors.New("unsupported directory for file name") // directory other than localization
+)
+
+// NameError describes the problem with the name of the file added to the Passbook.
+type NameError struct {
+	Name string // Name of the file
+	Err  error  // One of ErrDuplicateName, ErrCaseCollision, ErrUnsafeName or ErrDirectory
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("%v: %q", e.Err, e.Name)
+}
+
+func (e *NameError) Unwrap() error {
+	return e.Err
+}
+
 // Writer allows you to write files in Apple Passbook format.
 type Writer struct {
 	zip      *zip.Writer       // Packer
-	cert     *x509.Certificate // Certificate used for signature
-	priv     *rsa.PrivateKey   // Private key used for signature
+	signer   Signer            // Creates the signature of the manifest
 	hasPass  bool              // Flag that description of passbook added
 	manifest map[string]string // Hash of files
+	names    map[string]string // Names of added files by their lower case
+	modTime  time.Time         // Modification time of files for reproducible output
+	files    map[string][]byte // Content of files postponed until Close for reproducible output
+	err      error             // Error of the pass description, also returned on Close
 }
 
 // NewWriter creates a new Writer that allows you to create an Apple Passbook file.
 // As parameters, a stream is passed to which the given file will be written,
 // as well as the certificates that will be used to create the digital signature.
+//
+// The certificate must be a pass type certificate issued by Apple: the
+// identifiers of the pass are checked against it, so adding pass.json fails
+// with ErrNotPassCertificate if the certificate has no Apple's pass type
+// extension. Use NewSignerWriter with a Signer that is not CertificateSigner to
+// sign with other certificates.
 func NewWriter(out io.Writer, cert *x509.Certificate, priv *rsa.PrivateKey) *Writer {
+	return NewSignerWriter(out, &Identity{Certificate: cert, PrivateKey: priv})
+}
+
+// NewSignerWriter creates a new Writer that uses signer to create the digital
+// signature of the manifest.
+func NewSignerWriter(out io.Writer, signer Signer) *Writer {
 	return &Writer{
 		zip:      zip.NewWriter(out), // сжимаем при записи
-		cert:     cert,
-		priv:     priv,
+		signer:   signer,
 		manifest: make(map[string]string),
+		names:    make(map[string]string),
 	}
 }
 
+// SetReproducible makes the output of the Writer reproducible: all files in
+// the archive get the given modification time and are written on Close in the
+// order of their names, so the same files always produce the same archive.
+// The manifest is always encoded with sorted keys. Use Identity.SigningTime to
+// get the same signature too. SetReproducible must be called before Add.
+func (w *Writer) SetReproducible(modTime time.Time) {
+	w.modTime = modTime
+	w.files = make(map[string][]byte)
+}
+
 // Close finishes writing an Apple Passbook file and adds it automatically
 // generated manifest and signature file. At the time of creating a digital signature,
 // error, which in this case will also be returned. In addition, the error will return if
@@ -53,50 +101,72 @@ func (w *Writer) Close() (err error) {
 		}
 		w.zip = nil
 	}()
+	if w.err != nil {
+		return w.err
+	}
 	// Check that the main description has been added
 	if !w.hasPass {
 		return ErrNoPass
 	}
+	// Write the postponed files in the order of their names
+	for _, name := range sortedKeys(w.files) {
+		if err = w.write(name, w.files[name]); err != nil {
+			return err
+		}
+	}
 	// Translate the manifest into JSON
 	manifestData, err := json.MarshalIndent(w.manifest, "", "\t")
 	if err != nil {
 		return err
 	}
 	// We record the manifest data
-	manifestWriter, err := w.zip.Create("manifest.json")
-	if err != nil {
-		return err
-	}
-	if _, err = manifestWriter.Write(manifestData); err != nil {
+	if err = w.write("manifest.json", manifestData); err != nil {
 		return err
 	}
 	// Create a signature
-	signature, err := pkcs7.Sign(bytes.NewReader(manifestData), w.cert, w.priv)
+	signature, err := w.signer.Sign(manifestData)
 	if err != nil {
 		return err
 	}
 	// Write the signature to a file
-	signatureWriter, err := w.zip.Create("signature")
-	if err != nil {
-		return err
-	}
-	if _, err = signatureWriter.Write(signature); err != nil {
-		return err
+	return w.write("signature", signature)
+}
+
+// abort finishes the archive after the error without the manifest and the
+// signature.
+func (w *Writer) abort(err error) {
+	if w.err == nil {
+		w.err = err
 	}
-	return nil
+	w.Close()
 }
 
 // Add adds a new file to the Passbook. Only files with the extension .png and .strings are added.
-// Plus, a file called pass.json is added, which is a direct description.
+// Plus, a file called pass.json is added, which is a direct description, and
+// personalization.json with the reward program sign-up information.
 // All other files are ignored.
+//
+// If the signer is CertificateSigner, the empty pass type and team identifiers
+// in pass.json are filled from the certificate. If the identifiers do not match
+// the certificate or it is not a pass type certificate, pass.json is not added
+// and the error is returned by Add and then by Close.
+//
+// Files can be placed only in the root or in localization directories, such as
+// en.lproj. Add returns NameError for unsafe names, names in other directories
+// and names that were already added, including the same names in other case.
 func (w *Writer) Add(name string, r io.Reader) error {
 	if w.zip == nil {
 		return io.ErrClosedPipe // write stream closed
 	}
+	// Check that the name is relative and does not leave the archive
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsAny(name, "\\:") ||
+		path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return &NameError{Name: name, Err: ErrUnsafeName}
+	}
 	// Ignore unhandled files
 	switch path.Ext(name) {
-	case ".json": // From json-files we add only the description directly
-		if name != "pass.json" {
+	case ".json": // From json-files we add only the description and personalization
+		if name != "pass.json" && name != "personalization.json" {
 			return nil
 		}
 	case ".png": // picture
@@ -104,14 +174,49 @@ func (w *Writer) Add(name string, r io.Reader) error {
 	default: // Everything else is ignored
 		return nil
 	}
-	zipw, err := w.zip.Create(name) // Create a new file in the archive
-	if err != nil {
-		return err
+	// Only localization directories are supported
+	if dir, _ := path.Split(name); dir != "" &&
+		(strings.Count(dir, "/") > 1 || path.Ext(strings.TrimSuffix(dir, "/")) != ".lproj") {
+		return &NameError{Name: name, Err: ErrDirectory}
 	}
+	// iOS file system is case-insensitive, so names can't differ only in case
+	if added, ok := w.names[strings.ToLower(name)]; ok {
+		if added == name {
+			return &NameError{Name: name, Err: ErrDuplicateName}
+		}
+		return &NameError{Name: name, Err: ErrCaseCollision}
+	}
+	// Fill the identifiers of the pass from the signing certificate before
+	// anything is written
+	if signer, ok := w.signer.(CertificateSigner); ok && name == "pass.json" {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if data, err = fillPassIdentifiers(data, signer.SigningCertificate()); err != nil {
+			w.err = err
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	w.names[strings.ToLower(name)] = name
 	hash := sha1.New() // Initialize hash counting
-	// At the same time we write to the archive and consider a hash
-	if _, err := io.Copy(io.MultiWriter(zipw, hash), r); err != nil {
-		return err
+	if w.files != nil {
+		// Postpone writing to the archive until Close
+		data, err := io.ReadAll(io.TeeReader(r, hash))
+		if err != nil {
+			return err
+		}
+		w.files[name] = data
+	} else {
+		zipw, err := w.create(name) // Create a new file in the archive
+		if err != nil {
+			return err
+		}
+		// At the same time we write to the archive and consider a hash
+		if _, err := io.Copy(io.MultiWriter(zipw, hash), r); err != nil {
+			return err
+		}
 	}
 	w.manifest[name] = hex.EncodeToString(hash.Sum(nil)) // Save received hash
 	if name == "pass.json" {
@@ -119,3 +224,25 @@ func (w *Writer) Add(name string, r io.Reader) error {
 	}
 	return nil
 }
+
+// create creates a new file in the archive.
+func (w *Writer) create(name string) (io.Writer, error) {
+	if w.files == nil {
+		return w.zip.Create(name)
+	}
+	return w.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: w.modTime,
+	})
+}
+
+// write creates a new file in the archive with the given content.
+func (w *Writer) write(name string, data []byte) error {
+	zipw, err := w.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = zipw.Write(data)
+	return err
+}
@@ -0,0 +1,218 @@
+package passbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIdentity returns the identity with the self-signed certificate. If
+// passType is set, the certificate is like the pass type certificate issued by
+// Apple for the pass type and team identifiers of testPass.
+func testIdentity(t *testing.T, passType bool) *Identity {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if passType {
+		extension, _ := asn1.Marshal("pass.com.example.test")
+		template.Subject.OrganizationalUnit = []string{"A93A5CM278"}
+		template.Subject.ExtraNames = []pkix.AttributeTypeAndValue{
+			{Type: oidUserID, Value: "pass.com.example.test"}}
+		template.ExtraExtensions = []pkix.Extension{{Id: oidPassTypeExtension, Value: extension}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Identity{Certificate: cert, PrivateKey: priv}
+}
+
+func TestWriterAddNames(t *testing.T) {
+	pw := NewSignerWriter(io.Discard, nil)
+	for _, test := range []struct {
+		name string
+		err  error
+	}{
+		{"pass.json", nil},
+		{"icon.png", nil},
+		{"en.lproj/pass.strings", nil},
+		{"en.lproj/logo.png", nil},
+		{"readme.txt", nil}, // ignored
+		{"icon.png", ErrDuplicateName},
+		{"Icon.png", ErrCaseCollision},
+		{"EN.lproj/logo.png", ErrCaseCollision},
+		{"../icon.png", ErrUnsafeName},
+		{"/icon.png", ErrUnsafeName},
+		{"en.lproj/../strip.png", ErrUnsafeName},
+		{"./strip.png", ErrUnsafeName},
+		{"images\\strip.png", ErrUnsafeName},
+		{"images/strip.png", ErrDirectory},
+		{"en.lproj/images/strip.png", ErrDirectory},
+	} {
+		err := pw.Add(test.name, strings.NewReader("data"))
+		if !errors.Is(err, test.err) {
+			t.Errorf("Add(%q): %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWriterReproducible(t *testing.T) {
+	identity := testIdentity(t, true)
+	identity.SigningTime = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	modTime := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	passData, err := testPass("generic").Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"pass.json":             passData,
+		"icon.png":              []byte("icon"),
+		"logo.png":              []byte("logo"),
+		"en.lproj/pass.strings": []byte(`"a" = "b";`),
+	}
+	write := func(names ...string) []byte {
+		var buf bytes.Buffer
+		pw := NewSignerWriter(&buf, identity)
+		pw.SetReproducible(modTime)
+		for _, name := range names {
+			if err := pw.Add(name, bytes.NewReader(files[name])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	first := write("pass.json", "icon.png", "logo.png", "en.lproj/pass.strings")
+	second := write("en.lproj/pass.strings", "logo.png", "icon.png", "pass.json")
+	if !bytes.Equal(first, second) {
+		t.Fatal("archives with the same files differ")
+	}
+	zipr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range zipr.File {
+		names = append(names, file.Name)
+		if !file.Modified.Equal(modTime) {
+			t.Errorf("%s modified at %v, want %v", file.Name, file.Modified, modTime)
+		}
+	}
+	want := "en.lproj/pass.strings,icon.png,logo.png,pass.json,manifest.json,signature"
+	if strings.Join(names, ",") != want {
+		t.Errorf("files %q, want %s", names, want)
+	}
+	_, content := readZip(t, first)
+	sd, err := parseSignature(content["signature"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sd.verify(content["manifest.json"]); err != nil {
+		t.Error("bad signature:", err)
+	}
+	if signingTime := sd.signingTime(); !signingTime.Equal(identity.SigningTime) {
+		t.Errorf("signing time %v, want %v", signingTime, identity.SigningTime)
+	}
+	// the package with the modification time is reproducible too
+	pkg := &Package{
+		Pass:    testPass("generic"),
+		Images:  map[string][]byte{"icon.png": []byte("icon")},
+		ModTime: modTime,
+	}
+	data, err := pkg.Bytes(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := pkg.Bytes(identity); err != nil || !bytes.Equal(data, again) {
+		t.Errorf("package archives differ: %v", err)
+	}
+}
+
+func TestWriterIdentifiers(t *testing.T) {
+	pass := testPass("generic")
+	pass.PassTypeIdentifier, pass.TeamIdentifier = "", ""
+	emptyData, err := json.Marshal(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass.TeamIdentifier = "OTHERTEAM1"
+	otherData, err := json.Marshal(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := testIdentity(t, true)
+	// the empty identifiers are filled from the certificate
+	var buf bytes.Buffer
+	pw := NewSignerWriter(&buf, identity)
+	if err := pw.Add("pass.json", bytes.NewReader(emptyData)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, files := readZip(t, buf.Bytes())
+	var filled Pass
+	if err := json.Unmarshal(files["pass.json"], &filled); err != nil {
+		t.Fatal(err)
+	}
+	if filled.PassTypeIdentifier != "pass.com.example.test" || filled.TeamIdentifier != "A93A5CM278" {
+		t.Errorf("identifiers are not filled: %q, %q", filled.PassTypeIdentifier, filled.TeamIdentifier)
+	}
+	for _, test := range []struct {
+		name   string
+		signer Signer
+		data   []byte
+		err    error
+	}{
+		{"mismatch", identity, otherData, nil},
+		{"not pass certificate", testIdentity(t, false), emptyData, ErrNotPassCertificate},
+	} {
+		buf.Reset()
+		pw := NewSignerWriter(&buf, test.signer)
+		err := pw.Add("pass.json", bytes.NewReader(test.data))
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error", test.name)
+		case test.err != nil && !errors.Is(err, test.err):
+			t.Errorf("%s: %v, want %v", test.name, err, test.err)
+		case test.err == nil && !strings.Contains(err.Error(), "does not match"):
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if closeErr := pw.Close(); closeErr != err {
+			t.Errorf("%s: Close returned %v, want %v", test.name, closeErr, err)
+		}
+		if names, _ := readZip(t, buf.Bytes()); len(names) != 0 {
+			t.Errorf("%s: files are written: %q", test.name, names)
+		}
+	}
+	// other signers are not checked
+	pw = NewSignerWriter(io.Discard, testSigner{})
+	if err := pw.Add("pass.json", bytes.NewReader(otherData)); err != nil {
+		t.Error(err)
+	}
+}